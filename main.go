@@ -1,26 +1,33 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/mrzahrada/gen/example/mutations"
 	"github.com/mrzahrada/gen/pkg/gen"
 )
 
 func main() {
 
-	svc, err := gen.New()
+	out, args, err := gen.ParseOutput(os.Args[1:])
 	if err != nil {
-		panic(err)
+		os.Exit(2)
 	}
 
-	svc.AddMutation(mutations.Mutation{})
-
-	if err := svc.Build(); err != nil {
-		panic(err)
+	svc, err := gen.New(gen.WithOutput(out))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	if err := svc.Publish(); err != nil {
-		panic(err)
+	if err := svc.AddMutation(mutations.Mutation{}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	// fmt.Println(svc.String())
+	if err := svc.Run(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
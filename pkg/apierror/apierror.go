@@ -0,0 +1,124 @@
+// Package apierror defines errors returned by commands and queries to
+// callers. Generated mains serialize them per transport, other errors are
+// masked as internal errors.
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mrzahrada/gen/pkg/logging"
+	"github.com/mrzahrada/gen/pkg/middleware"
+	"github.com/mrzahrada/gen/pkg/validate"
+)
+
+// Code classifies error for callers
+type Code string
+
+const (
+	InvalidArgument   = Code("INVALID_ARGUMENT")
+	Unauthenticated   = Code("UNAUTHENTICATED")
+	PermissionDenied  = Code("PERMISSION_DENIED")
+	NotFound          = Code("NOT_FOUND")
+	AlreadyExists     = Code("ALREADY_EXISTS")
+	Conflict          = Code("CONFLICT")
+	ResourceExhausted = Code("RESOURCE_EXHAUSTED")
+	Unavailable       = Code("UNAVAILABLE")
+	Internal          = Code("INTERNAL")
+)
+
+var httpStatus = map[Code]int{
+	InvalidArgument:   http.StatusBadRequest,
+	Unauthenticated:   http.StatusUnauthorized,
+	PermissionDenied:  http.StatusForbidden,
+	NotFound:          http.StatusNotFound,
+	AlreadyExists:     http.StatusConflict,
+	Conflict:          http.StatusConflict,
+	ResourceExhausted: http.StatusTooManyRequests,
+	Unavailable:       http.StatusServiceUnavailable,
+	Internal:          http.StatusInternalServerError,
+}
+
+var graphQLCode = map[Code]string{
+	InvalidArgument:   "BAD_USER_INPUT",
+	Unauthenticated:   "UNAUTHENTICATED",
+	PermissionDenied:  "FORBIDDEN",
+	NotFound:          "NOT_FOUND",
+	AlreadyExists:     "CONFLICT",
+	Conflict:          "CONFLICT",
+	ResourceExhausted: "RATE_LIMITED",
+	Unavailable:       "SERVICE_UNAVAILABLE",
+	Internal:          "INTERNAL_SERVER_ERROR",
+}
+
+// HTTPStatus returns HTTP status code of c, unknown codes are internal
+// errors
+func (c Code) HTTPStatus() int {
+	if status, ok := httpStatus[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// GraphQLCode returns GraphQL error code of c, unknown codes are internal
+// errors
+func (c Code) GraphQLCode() string {
+	if code, ok := graphQLCode[c]; ok {
+		return code
+	}
+	return graphQLCode[Internal]
+}
+
+// Error is returned to callers as it is
+type Error struct {
+	Code    Code                   `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// New returns error of code with message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Errorf returns error of code with formatted message
+func Errorf(code Code, format string, args ...interface{}) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// WithDetail sets detail key of error returned to callers
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = map[string]interface{}{}
+	}
+	e.Details[key] = value
+	return e
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// From converts err returned by handler into Error. Validation errors are
+// invalid arguments listing invalid fields, other errors are logged with
+// request ID and masked as internal errors.
+func From(ctx context.Context, err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var v *validate.ValidationError
+	if errors.As(err, &v) {
+		e = New(InvalidArgument, v.Message)
+		if len(v.Fields) > 0 {
+			e.WithDetail("fields", v.Fields)
+		}
+		return e
+	}
+
+	ctx = middleware.EnsureRequestID(ctx)
+	logging.FromContext(ctx).Error("internal error", "error", err)
+	return New(Internal, "internal error").WithDetail("request_id", middleware.RequestIDFrom(ctx))
+}
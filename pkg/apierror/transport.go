@@ -0,0 +1,107 @@
+package apierror
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+	"github.com/mrzahrada/gen/pkg/middleware"
+)
+
+// RequestIDHeader carries request ID of HTTP requests and responses
+const RequestIDHeader = "X-Request-Id"
+
+// Lambda serializes errors of h for direct invocation. Error code is
+// reported as errorType and the whole error as JSON in errorMessage.
+func Lambda(h middleware.Handler) middleware.Handler {
+	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		ctx = middleware.EnsureRequestID(ctx)
+		result, err := h(ctx, payload)
+		if err != nil {
+			e := From(ctx, err)
+			message, _ := json.Marshal(e)
+			return nil, messages.InvokeResponse_Error{
+				Type:    string(e.Code),
+				Message: string(message),
+			}
+		}
+		return result, nil
+	}
+}
+
+// GraphQL serializes errors of h for GraphQL resolvers, e.g. AppSync
+// direct Lambda resolvers. GraphQL error code is reported as errorType and
+// error message as errorMessage.
+func GraphQL(h middleware.Handler) middleware.Handler {
+	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		ctx = middleware.EnsureRequestID(ctx)
+		result, err := h(ctx, payload)
+		if err != nil {
+			e := From(ctx, err)
+			return nil, messages.InvokeResponse_Error{
+				Type:    e.Code.GraphQLCode(),
+				Message: e.Message,
+			}
+		}
+		return result, nil
+	}
+}
+
+// HTTP serves h behind HTTP API or function URL. Request body is the
+// payload, result is JSON body of response and errors are JSON bodies with
+// status code of the error.
+func HTTP(h middleware.Handler) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		// HTTP API passes header names in lower case
+		if id := req.Headers["x-request-id"]; id != "" {
+			ctx = middleware.WithRequestID(ctx, id)
+		}
+		ctx = middleware.EnsureRequestID(ctx)
+
+		body := []byte(req.Body)
+		if req.IsBase64Encoded {
+			decoded, err := base64.StdEncoding.DecodeString(req.Body)
+			if err != nil {
+				return response(ctx, New(InvalidArgument, "body is not base64 encoded"))
+			}
+			body = decoded
+		}
+		if len(body) == 0 {
+			body = []byte("null")
+		}
+		result, err := h(ctx, body)
+		if err != nil {
+			return response(ctx, From(ctx, err))
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return response(ctx, From(ctx, err))
+		}
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: 200,
+			Headers:    headers(ctx),
+			Body:       string(data),
+		}, nil
+	}
+}
+
+func response(ctx context.Context, e *Error) (events.APIGatewayV2HTTPResponse, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return events.APIGatewayV2HTTPResponse{}, err
+	}
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: e.Code.HTTPStatus(),
+		Headers:    headers(ctx),
+		Body:       string(data),
+	}, nil
+}
+
+func headers(ctx context.Context) map[string]string {
+	return map[string]string{
+		"Content-Type":  "application/json",
+		RequestIDHeader: middleware.RequestIDFrom(ctx),
+	}
+}
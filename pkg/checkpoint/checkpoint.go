@@ -0,0 +1,57 @@
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// EnvTable is environment variable with name of DynamoDB table used by
+// FromEnv
+const EnvTable = "CHECKPOINT_TABLE"
+
+// Key identifies shard of a stream. Shard IDs are unique within a stream
+// only, so that checkpoints of streams sharing a table are kept apart.
+type Key struct {
+	// Stream is ARN of the stream
+	Stream string
+	Shard  string
+}
+
+// Store keeps sequence number of the last processed record per shard
+type Store interface {
+	// Get returns checkpoint of shard, empty when there is none
+	Get(ctx context.Context, key Key) (string, error)
+	Put(ctx context.Context, key Key, sequence string) error
+}
+
+// FromEnv returns DynamoDB store with table set by CHECKPOINT_TABLE
+func FromEnv() (Store, error) {
+	table := os.Getenv(EnvTable)
+	if table == "" {
+		return nil, fmt.Errorf("checkpoint: %s is not set", EnvTable)
+	}
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	return NewDynamoDB(dynamodb.New(sess), table), nil
+}
+
+// Compare compares decimal sequence numbers a and b. Empty sequence
+// number precedes all others.
+func Compare(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
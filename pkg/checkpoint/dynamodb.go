@@ -0,0 +1,56 @@
+package checkpoint
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// DynamoDB stores checkpoints in a table with string partition key stream
+// and string sort key shard
+type DynamoDB struct {
+	client dynamodbiface.DynamoDBAPI
+	table  string
+}
+
+// NewDynamoDB -
+func NewDynamoDB(client dynamodbiface.DynamoDBAPI, table string) *DynamoDB {
+	return &DynamoDB{
+		client: client,
+		table:  table,
+	}
+}
+
+// Get - implements Store.Get
+func (store *DynamoDB) Get(ctx context.Context, key Key) (string, error) {
+	output, err := store.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(store.table),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]*dynamodb.AttributeValue{
+			"stream": {S: aws.String(key.Stream)},
+			"shard":  {S: aws.String(key.Shard)},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if sequence, ok := output.Item["sequence"]; ok && sequence.S != nil {
+		return *sequence.S, nil
+	}
+	return "", nil
+}
+
+// Put - implements Store.Put
+func (store *DynamoDB) Put(ctx context.Context, key Key, sequence string) error {
+	_, err := store.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(store.table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"stream":   {S: aws.String(key.Stream)},
+			"shard":    {S: aws.String(key.Shard)},
+			"sequence": {S: aws.String(sequence)},
+		},
+	})
+	return err
+}
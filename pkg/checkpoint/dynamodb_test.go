@@ -0,0 +1,45 @@
+package checkpoint
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// table keeps items by stream and shard attributes
+type table struct {
+	dynamodbiface.DynamoDBAPI
+	items map[[2]string]map[string]*dynamodb.AttributeValue
+}
+
+func (t *table) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	key := [2]string{aws.StringValue(input.Key["stream"].S), aws.StringValue(input.Key["shard"].S)}
+	return &dynamodb.GetItemOutput{Item: t.items[key]}, nil
+}
+
+func (t *table) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	key := [2]string{aws.StringValue(input.Item["stream"].S), aws.StringValue(input.Item["shard"].S)}
+	t.items[key] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestDynamoDBKeyedByStream(t *testing.T) {
+	ctx := context.Background()
+	store := NewDynamoDB(&table{items: map[[2]string]map[string]*dynamodb.AttributeValue{}}, "checkpoints")
+	orders := Key{Stream: "arn:aws:kinesis:us-east-1:123456789012:stream/orders", Shard: "shardId-000000000000"}
+	users := Key{Stream: "arn:aws:kinesis:us-east-1:123456789012:stream/users", Shard: "shardId-000000000000"}
+
+	if err := store.Put(ctx, orders, "42"); err != nil {
+		t.Fatal(err)
+	}
+	if sequence, err := store.Get(ctx, orders); err != nil || sequence != "42" {
+		t.Errorf("orders checkpoint %q, %v, want 42", sequence, err)
+	}
+	if sequence, err := store.Get(ctx, users); err != nil || sequence != "" {
+		t.Errorf("users checkpoint %q, %v, want none", sequence, err)
+	}
+}
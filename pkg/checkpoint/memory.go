@@ -0,0 +1,34 @@
+package checkpoint
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory keeps checkpoints in memory, intended for tests
+type Memory struct {
+	mu          sync.Mutex
+	checkpoints map[Key]string
+}
+
+// NewMemory -
+func NewMemory() *Memory {
+	return &Memory{
+		checkpoints: map[Key]string{},
+	}
+}
+
+// Get - implements Store.Get
+func (m *Memory) Get(ctx context.Context, key Key) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.checkpoints[key], nil
+}
+
+// Put - implements Store.Put
+func (m *Memory) Put(ctx context.Context, key Key, sequence string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints[key] = sequence
+	return nil
+}
@@ -0,0 +1,108 @@
+package deadletter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Environment variables configuring sink returned by FromEnv
+const (
+	EnvSink   = "DEAD_LETTER_SINK"
+	EnvTarget = "DEAD_LETTER_TARGET"
+)
+
+// Letter is a quarantined event
+type Letter struct {
+	ID       string    `json:"id"`
+	Data     string    `json:"data"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	Arrived  time.Time `json:"arrived"`
+	At       time.Time `json:"at"`
+}
+
+// Sink stores quarantined events
+type Sink interface {
+	PutDeadLetter(ctx context.Context, letter Letter) error
+}
+
+// FromEnv returns sink configured by DEAD_LETTER_SINK (sqs or s3) and
+// DEAD_LETTER_TARGET (queue URL or bucket/prefix)
+func FromEnv() (Sink, error) {
+	target := os.Getenv(EnvTarget)
+	if target == "" {
+		return nil, fmt.Errorf("deadletter: %s is not set", EnvTarget)
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind := os.Getenv(EnvSink); kind {
+	case "sqs":
+		return NewSQS(sqs.New(sess), target), nil
+	case "s3":
+		bucket, prefix := target, ""
+		if i := strings.Index(target, "/"); i >= 0 {
+			bucket, prefix = target[:i], target[i+1:]
+		}
+		return NewS3(s3.New(sess), bucket, prefix), nil
+	default:
+		return nil, fmt.Errorf("deadletter: unknown sink %q", kind)
+	}
+}
+
+// Tracker counts failed attempts of records and decides when a record is
+// quarantined. Attempts are tracked in memory of a running function, age
+// of a record covers retries handled by other instances.
+type Tracker struct {
+	MaxAttempts int
+	MaxAge      time.Duration
+
+	mu       sync.Mutex
+	attempts map[string]int
+	now      func() time.Time
+}
+
+// NewTracker -
+func NewTracker(maxAttempts int, maxAge time.Duration) *Tracker {
+	return &Tracker{
+		MaxAttempts: maxAttempts,
+		MaxAge:      maxAge,
+		attempts:    map[string]int{},
+		now:         time.Now,
+	}
+}
+
+// Fail records failed attempt of record id and returns number of attempts
+// and whether the record should be quarantined
+func (t *Tracker) Fail(id string, arrived time.Time) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.attempts[id]++
+	attempts := t.attempts[id]
+	if t.MaxAttempts > 0 && attempts >= t.MaxAttempts {
+		return attempts, true
+	}
+	if t.MaxAge > 0 && !arrived.IsZero() && t.now().Sub(arrived) >= t.MaxAge {
+		return attempts, true
+	}
+	return attempts, false
+}
+
+// Done forgets attempts of record id
+func (t *Tracker) Done(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.attempts, id)
+}
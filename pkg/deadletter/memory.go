@@ -0,0 +1,32 @@
+package deadletter
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory keeps letters in memory, intended for tests
+type Memory struct {
+	mu      sync.Mutex
+	letters []Letter
+}
+
+// NewMemory -
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// PutDeadLetter - implements Sink.PutDeadLetter
+func (m *Memory) PutDeadLetter(ctx context.Context, letter Letter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.letters = append(m.letters, letter)
+	return nil
+}
+
+// Letters returns stored letters
+func (m *Memory) Letters() []Letter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Letter{}, m.letters...)
+}
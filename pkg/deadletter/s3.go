@@ -0,0 +1,44 @@
+package deadletter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// S3 stores letters as JSON objects under prefix
+type S3 struct {
+	client s3iface.S3API
+	bucket string
+	prefix string
+}
+
+// NewS3 -
+func NewS3(client s3iface.S3API, bucket, prefix string) *S3 {
+	return &S3{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+// PutDeadLetter - implements Sink.PutDeadLetter
+func (s *S3) PutDeadLetter(ctx context.Context, letter Letter) error {
+	body, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	key := s.prefix + strings.Replace(letter.ID, ":", "/", -1) + ".json"
+	_, err = s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
@@ -0,0 +1,37 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+// SQS sends letters as JSON messages to a queue
+type SQS struct {
+	client   sqsiface.SQSAPI
+	queueURL string
+}
+
+// NewSQS -
+func NewSQS(client sqsiface.SQSAPI, queueURL string) *SQS {
+	return &SQS{
+		client:   client,
+		queueURL: queueURL,
+	}
+}
+
+// PutDeadLetter - implements Sink.PutDeadLetter
+func (s *SQS) PutDeadLetter(ctx context.Context, letter Letter) error {
+	body, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.SendMessageWithContext(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(s.queueURL),
+		MessageBody: aws.String(string(body)),
+	})
+	return err
+}
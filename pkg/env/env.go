@@ -0,0 +1,155 @@
+// Package env loads configuration of services from environment variables.
+// Fields are read from variables named by env tag, e.g.
+//
+//	type Config struct {
+//		Table   string        `env:"TABLE,required"`
+//		Timeout time.Duration `env:"TIMEOUT"`
+//	}
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Load sets fields of struct pointed by v from environment variables.
+// Unset variables keep field values unless they are required.
+func Load(v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return errors.New("env: pointer to struct expected")
+	}
+	value = value.Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag, ok := field.Tag.Lookup("env")
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+		name, required := tag, false
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, required = tag[:i], tag[i+1:] == "required"
+		}
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			if required {
+				return fmt.Errorf("env: %s is required", name)
+			}
+			continue
+		}
+		if err := set(value.Field(i), s); err != nil {
+			return fmt.Errorf("env: %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Tagged reports whether t is a struct, or pointer to struct, with env
+// tags
+func Tagged(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("env"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns error of the first field with env tag of struct t, or
+// pointer to struct, which Load cannot set
+func Check(t reflect.Type) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("env: %s is not a struct", t)
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("env")
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+		if i := strings.Index(tag, ","); i >= 0 {
+			tag = tag[:i]
+		}
+		if !supported(field.Type) {
+			return fmt.Errorf("env: %s: unsupported type %s of %s.%s", tag, field.Type, t, field.Name)
+		}
+	}
+	return nil
+}
+
+// supported reports whether set can set field of type t
+func supported(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+func set(field reflect.Value, s string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		field.Set(reflect.ValueOf(strings.Split(s, ",")).Convert(field.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
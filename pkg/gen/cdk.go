@@ -0,0 +1,213 @@
+package gen
+
+import (
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+var cdkTmpl = `// DO NOT EDIT! Generated code
+import * as cdk from "@aws-cdk/core";
+import * as dynamodb from "@aws-cdk/aws-dynamodb";
+import * as ec2 from "@aws-cdk/aws-ec2";
+import * as events from "@aws-cdk/aws-events";
+import * as targets from "@aws-cdk/aws-events-targets";
+import * as apigw from "@aws-cdk/aws-apigatewayv2";
+import { HttpLambdaIntegration } from "@aws-cdk/aws-apigatewayv2-integrations";
+import * as iam from "@aws-cdk/aws-iam";
+import * as kinesis from "@aws-cdk/aws-kinesis";
+import * as lambda from "@aws-cdk/aws-lambda";
+import * as s3 from "@aws-cdk/aws-s3";
+import * as sns from "@aws-cdk/aws-sns";
+import * as sqs from "@aws-cdk/aws-sqs";
+import {
+  DynamoEventSource,
+  KinesisEventSource,
+  SnsEventSource,
+  SqsEventSource,
+} from "@aws-cdk/aws-lambda-event-sources";
+{{ .Declarations }}
+export const config: Config = {{ .Config }};
+
+export interface GenServiceProps {
+  readonly config?: Config;
+  // source of mutation events matching its configured source
+  readonly stream?: kinesis.IStream;
+  readonly table?: dynamodb.ITable;
+  readonly queue?: sqs.IQueue;
+  readonly topic?: sns.ITopic;
+  readonly eventBus?: events.IEventBus;
+  readonly startingPosition?: lambda.StartingPosition;
+  readonly batchSize?: number;
+  // vpc is required when any method is configured with subnets
+  readonly vpc?: ec2.IVpc;
+}
+
+export class GenService extends cdk.Construct {
+  public readonly commands: { [name: string]: lambda.Function } = {};
+  public readonly queries: { [name: string]: lambda.Function } = {};
+  public readonly functions: { [name: string]: lambda.Function } = {};
+  public readonly mutation?: lambda.Function;
+  // httpApi routes commands and queries with http transport
+  public readonly httpApi?: apigw.HttpApi;
+
+  constructor(scope: cdk.Construct, id: string, props: GenServiceProps = {}) {
+    super(scope, id);
+
+    const cfg = props.config ?? config;
+    const bucket = s3.Bucket.fromBucketName(this, "Bucket", cfg.bucket);
+
+    const fn = (kind: string, method: ConfigMethod): lambda.Function => {
+      const id = kind + method.name;
+      let vpc: Partial<lambda.FunctionProps> = {};
+      if (method.vpc) {
+        if (!props.vpc) {
+          throw new Error("GenService: vpc is required for " + id);
+        }
+        const subnets = method.vpc.subnetIds.map((subnetId, i) =>
+          ec2.Subnet.fromSubnetId(this, ` + "`${id}Subnet${i}`" + `, subnetId),
+        );
+        const securityGroups = method.vpc.securityGroupIds.map((groupId, i) =>
+          ec2.SecurityGroup.fromSecurityGroupId(this, ` + "`${id}SecurityGroup${i}`" + `, groupId),
+        );
+        vpc = { vpc: props.vpc, vpcSubnets: { subnets }, securityGroups };
+      }
+
+      const f = new lambda.Function(this, id, {
+        functionName: ` + "`${cfg.service}-${kind}-${method.name}`" + `,
+        code: lambda.Code.fromBucket(bucket, method.s3Key),
+        handler: method.handler,
+        runtime: new lambda.Runtime(method.runtime.toLowerCase(), lambda.RuntimeFamily.GO),
+        memorySize: method.memorySize,
+        timeout: method.timeout ? cdk.Duration.seconds(method.timeout) : undefined,
+        reservedConcurrentExecutions: method.reservedConcurrency ?? undefined,
+        environment: method.environment,
+        ...vpc,
+      });
+      bucket.grantRead(f);
+      for (const policy of method.policies ?? []) {
+        f.addToRolePolicy(
+          new iam.PolicyStatement({
+            effect: policy.effect === "Deny" ? iam.Effect.DENY : iam.Effect.ALLOW,
+            actions: policy.actions,
+            resources: policy.resources,
+          }),
+        );
+      }
+      return f;
+    };
+
+    if ([...(cfg.commands ?? []), ...(cfg.queries ?? [])].some((method) => method.transport === "http")) {
+      this.httpApi = new apigw.HttpApi(this, "HttpApi", { apiName: cfg.service });
+    }
+    const route = (path: string, method: ConfigMethod, f: lambda.Function) => {
+      if (!this.httpApi || method.transport !== "http") {
+        return;
+      }
+      this.httpApi.addRoutes({
+        path: ` + "`/${path}/${method.name}`" + `,
+        methods: [apigw.HttpMethod.POST],
+        integration: new HttpLambdaIntegration(` + "`${path}${method.name}Integration`" + `, f),
+      });
+    };
+
+    for (const method of cfg.commands ?? []) {
+      this.commands[method.name] = fn("command", method);
+      route("commands", method, this.commands[method.name]);
+    }
+    for (const method of cfg.queries ?? []) {
+      this.queries[method.name] = fn("query", method);
+      route("queries", method, this.queries[method.name]);
+    }
+    for (const method of cfg.functions ?? []) {
+      this.functions[method.name] = fn("function", method);
+    }
+
+    if (cfg.mutations) {
+      const mutation = cfg.mutations;
+      const required = <T>(value: T | undefined, name: string): T => {
+        if (!value) {
+          throw new Error(` + "`GenService: ${name} is required for ${mutation.source} mutation`" + `);
+        }
+        return value;
+      };
+      const streamProps = {
+        startingPosition: props.startingPosition ?? lambda.StartingPosition.TRIM_HORIZON,
+        batchSize: props.batchSize,
+        reportBatchItemFailures: mutation.reportBatchItemFailures,
+      };
+
+      this.mutation = fn("mutation", mutation);
+      // event sources grant read permissions on the source
+      switch (mutation.source ?? "kinesis") {
+        case "kinesis":
+          this.mutation.addEventSource(new KinesisEventSource(required(props.stream, "stream"), streamProps));
+          break;
+        case "dynamodb":
+          this.mutation.addEventSource(new DynamoEventSource(required(props.table, "table"), streamProps));
+          break;
+        case "sqs":
+          this.mutation.addEventSource(
+            new SqsEventSource(required(props.queue, "queue"), {
+              batchSize: props.batchSize,
+              reportBatchItemFailures: mutation.reportBatchItemFailures,
+            }),
+          );
+          break;
+        case "sns":
+          this.mutation.addEventSource(new SnsEventSource(required(props.topic, "topic")));
+          break;
+        case "eventbridge":
+          new events.Rule(this, "MutationRule", {
+            eventBus: props.eventBus,
+            eventPattern: { detailType: cfg.events },
+            targets: [new targets.LambdaFunction(this.mutation)],
+          });
+          break;
+        default:
+          throw new Error("GenService: unknown mutation source " + mutation.source);
+      }
+    }
+  }
+}
+`
+
+// CDK returns TypeScript module with typed Config and GenService construct
+// deploying the service.
+func (svc *Service) CDK() (string, error) {
+	tmpl, err := template.New("cdk").Parse(cdkTmpl)
+	if err != nil {
+		return "", err
+	}
+
+	ts := newTypeScript()
+	ts.typeOf(reflect.TypeOf(Config{}))
+	var declarations strings.Builder
+	for i := 0; i < len(ts.order); i++ {
+		declarations.WriteString("\n")
+		ts.declare(&declarations, ts.order[i])
+	}
+
+	var b strings.Builder
+	err = tmpl.Execute(&b, struct {
+		Declarations string
+		Config       string
+	}{
+		Declarations: declarations.String(),
+		Config:       svc.String(),
+	})
+	return b.String(), err
+}
+
+// WriteCDK writes CDK module into file p
+func (svc *Service) WriteCDK(p string) error {
+	content, err := svc.CDK()
+	if err != nil {
+		return err
+	}
+	if err := writeFile(p, content); err != nil {
+		return err
+	}
+	svc.Logger().Info("generated cdk file", "path", p)
+	return nil
+}
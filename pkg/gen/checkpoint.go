@@ -0,0 +1,43 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mrzahrada/gen/pkg/checkpoint"
+)
+
+// Checkpoint configures tracking of processed records of mutation
+type Checkpoint struct {
+	tableArn string
+}
+
+// WithCheckpoint skips records at or below the last processed sequence
+// number of a shard stored in DynamoDB table. Table has string partition
+// key stream, the stream ARN, and string sort key shard, the shard ID.
+// Checkpoints are committed after successful Push.
+func WithCheckpoint(tableArn string) Option {
+	return func(o *options) {
+		o.checkpoint = &Checkpoint{tableArn: tableArn}
+	}
+}
+
+// configure validates checkpoint of mutation reading source and adds
+// environment and permissions required by the store into settings
+func (c *Checkpoint) configure(source Source, settings *Settings) error {
+	if source != KinesisSource {
+		return errors.New("checkpoint requires kinesis source")
+	}
+
+	// arn:aws:dynamodb:region:account:table/name
+	parts := strings.Split(c.tableArn, ":")
+	if len(parts) != 6 || parts[2] != "dynamodb" || !strings.HasPrefix(parts[5], "table/") {
+		return fmt.Errorf("invalid table arn %q", c.tableArn)
+	}
+
+	if err := Env(checkpoint.EnvTable, strings.TrimPrefix(parts[5], "table/"))(settings); err != nil {
+		return err
+	}
+	return Allow([]string{"dynamodb:GetItem", "dynamodb:PutItem"}, c.tableArn)(settings)
+}
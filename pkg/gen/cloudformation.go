@@ -0,0 +1,301 @@
+package gen
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type cfnTemplate struct {
+	Version     string                 `json:"AWSTemplateFormatVersion"`
+	Description string                 `json:"Description,omitempty"`
+	Parameters  map[string]cfnParam    `json:"Parameters,omitempty"`
+	Resources   map[string]cfnResource `json:"Resources"`
+	Outputs     map[string]cfnOutput   `json:"Outputs,omitempty"`
+}
+
+type cfnParam struct {
+	Type        string `json:"Type"`
+	Default     string `json:"Default,omitempty"`
+	Description string `json:"Description,omitempty"`
+}
+
+type cfnResource struct {
+	Type       string                 `json:"Type"`
+	DependsOn  []string               `json:"DependsOn,omitempty"`
+	Properties map[string]interface{} `json:"Properties"`
+}
+
+type cfnOutput struct {
+	Value interface{} `json:"Value"`
+}
+
+func cfnRef(name string) map[string]interface{} {
+	return map[string]interface{}{"Ref": name}
+}
+
+func cfnGetAtt(name, attr string) map[string]interface{} {
+	return map[string]interface{}{"Fn::GetAtt": []string{name, attr}}
+}
+
+// CloudFormation returns CloudFormation template with a function, role,
+// log group and output per command, query and function. Commands and
+// queries with HTTP transport are routed by HTTP API. Mutation is
+// subscribed to its source passed as a parameter.
+func (cfg *Config) CloudFormation() ([]byte, error) {
+	tmpl := cfnTemplate{
+		Version:     "2010-09-09",
+		Description: cfg.ServiceName,
+		Parameters:  map[string]cfnParam{},
+		Resources:   map[string]cfnResource{},
+		Outputs:     map[string]cfnOutput{},
+	}
+
+	for _, fn := range cfg.lambdas() {
+		id := fn.ID() + "Function"
+		role := fn.ID() + "Role"
+		name := fn.FunctionName(cfg.ServiceName)
+
+		tmpl.Resources[role] = cfnResource{
+			Type:       "AWS::IAM::Role",
+			Properties: cfnRole(fn),
+		}
+
+		tmpl.Resources[fn.ID()+"LogGroup"] = cfnResource{
+			Type: "AWS::Logs::LogGroup",
+			Properties: map[string]interface{}{
+				"LogGroupName": "/aws/lambda/" + name,
+			},
+		}
+
+		properties := map[string]interface{}{
+			"FunctionName": name,
+			"Code": map[string]interface{}{
+				"S3Bucket": cfg.Bucket,
+				"S3Key":    fn.Method.S3Key,
+			},
+			"Handler": fn.Method.Handler,
+			"Runtime": strings.ToLower(fn.Method.Runtime),
+			"Role":    cfnGetAtt(role, "Arn"),
+		}
+		settings := fn.Method.Settings
+		if settings.MemorySize > 0 {
+			properties["MemorySize"] = settings.MemorySize
+		}
+		if settings.Timeout > 0 {
+			properties["Timeout"] = settings.Timeout
+		}
+		if settings.ReservedConcurrency != nil {
+			properties["ReservedConcurrentExecutions"] = *settings.ReservedConcurrency
+		}
+		if len(settings.Environment) > 0 {
+			properties["Environment"] = map[string]interface{}{
+				"Variables": settings.Environment,
+			}
+		}
+		if settings.VPC != nil {
+			properties["VpcConfig"] = map[string]interface{}{
+				"SubnetIds":        settings.VPC.SubnetIDs,
+				"SecurityGroupIds": settings.VPC.SecurityGroupIDs,
+			}
+		}
+
+		tmpl.Resources[id] = cfnResource{
+			Type:       "AWS::Lambda::Function",
+			DependsOn:  []string{fn.ID() + "LogGroup"},
+			Properties: properties,
+		}
+
+		tmpl.Outputs[id+"Arn"] = cfnOutput{
+			Value: cfnGetAtt(id, "Arn"),
+		}
+
+		if fn.Route() != "" {
+			cfg.cfnRoute(&tmpl, fn)
+		}
+		if fn.Kind == MutationType {
+			cfg.cfnSource(&tmpl, fn)
+		}
+	}
+
+	return json.MarshalIndent(tmpl, "", "  ")
+}
+
+// cfnRoute routes HTTP API requests to fn, API is created with the first
+// route
+func (cfg *Config) cfnRoute(tmpl *cfnTemplate, fn lambdaFunction) {
+	id := fn.ID() + "Function"
+	if _, ok := tmpl.Resources["HttpApi"]; !ok {
+		tmpl.Resources["HttpApi"] = cfnResource{
+			Type: "AWS::ApiGatewayV2::Api",
+			Properties: map[string]interface{}{
+				"Name":         cfg.ServiceName,
+				"ProtocolType": "HTTP",
+			},
+		}
+		tmpl.Resources["HttpApiStage"] = cfnResource{
+			Type: "AWS::ApiGatewayV2::Stage",
+			Properties: map[string]interface{}{
+				"ApiId":      cfnRef("HttpApi"),
+				"StageName":  "$default",
+				"AutoDeploy": true,
+			},
+		}
+		tmpl.Outputs["HttpApiUrl"] = cfnOutput{
+			Value: cfnGetAtt("HttpApi", "ApiEndpoint"),
+		}
+	}
+
+	tmpl.Resources[fn.ID()+"Integration"] = cfnResource{
+		Type: "AWS::ApiGatewayV2::Integration",
+		Properties: map[string]interface{}{
+			"ApiId":                cfnRef("HttpApi"),
+			"IntegrationType":      "AWS_PROXY",
+			"IntegrationUri":       cfnGetAtt(id, "Arn"),
+			"PayloadFormatVersion": "2.0",
+		},
+	}
+	tmpl.Resources[fn.ID()+"Route"] = cfnResource{
+		Type: "AWS::ApiGatewayV2::Route",
+		Properties: map[string]interface{}{
+			"ApiId":    cfnRef("HttpApi"),
+			"RouteKey": fn.Route(),
+			"Target": map[string]interface{}{
+				"Fn::Join": []interface{}{"/", []interface{}{"integrations", cfnRef(fn.ID() + "Integration")}},
+			},
+		},
+	}
+	tmpl.Resources[fn.ID()+"Permission"] = cfnResource{
+		Type: "AWS::Lambda::Permission",
+		Properties: map[string]interface{}{
+			"Action":       "lambda:InvokeFunction",
+			"FunctionName": cfnRef(id),
+			"Principal":    "apigateway.amazonaws.com",
+			"SourceArn": map[string]interface{}{
+				"Fn::Sub": "arn:${AWS::Partition}:execute-api:${AWS::Region}:${AWS::AccountId}:${HttpApi}/*/*",
+			},
+		},
+	}
+}
+
+// cfnSource subscribes mutation fn to its source
+func (cfg *Config) cfnSource(tmpl *cfnTemplate, fn lambdaFunction) {
+	id := fn.ID() + "Function"
+	source := fn.source()
+	spec := sources[source]
+
+	tmpl.Parameters[spec.param] = cfnParam{
+		Type:        "String",
+		Description: spec.description,
+	}
+
+	switch source {
+	case SNSSource:
+		tmpl.Resources[fn.ID()+"Subscription"] = cfnResource{
+			Type: "AWS::SNS::Subscription",
+			Properties: map[string]interface{}{
+				"Protocol": "lambda",
+				"Endpoint": cfnGetAtt(id, "Arn"),
+				"TopicArn": cfnRef(spec.param),
+			},
+		}
+		tmpl.Resources[fn.ID()+"Permission"] = cfnResource{
+			Type: "AWS::Lambda::Permission",
+			Properties: map[string]interface{}{
+				"Action":       "lambda:InvokeFunction",
+				"FunctionName": cfnRef(id),
+				"Principal":    "sns.amazonaws.com",
+				"SourceArn":    cfnRef(spec.param),
+			},
+		}
+	case EventBridgeSource:
+		tmpl.Parameters[spec.param] = cfnParam{
+			Type:        "String",
+			Default:     "default",
+			Description: spec.description,
+		}
+		tmpl.Resources[fn.ID()+"Rule"] = cfnResource{
+			Type: "AWS::Events::Rule",
+			Properties: map[string]interface{}{
+				"EventBusName": cfnRef(spec.param),
+				"EventPattern": map[string]interface{}{
+					"detail-type": cfg.Events,
+				},
+				"Targets": []map[string]interface{}{{
+					"Id":  fn.ID(),
+					"Arn": cfnGetAtt(id, "Arn"),
+				}},
+			},
+		}
+		tmpl.Resources[fn.ID()+"Permission"] = cfnResource{
+			Type: "AWS::Lambda::Permission",
+			Properties: map[string]interface{}{
+				"Action":       "lambda:InvokeFunction",
+				"FunctionName": cfnRef(id),
+				"Principal":    "events.amazonaws.com",
+				"SourceArn":    cfnGetAtt(fn.ID()+"Rule", "Arn"),
+			},
+		}
+	default:
+		mapping := map[string]interface{}{
+			"EventSourceArn": cfnRef(spec.param),
+			"FunctionName":   cfnRef(id),
+		}
+		if source.Stream() {
+			mapping["StartingPosition"] = "TRIM_HORIZON"
+		}
+		if fn.Method.ReportBatchItemFailures {
+			mapping["FunctionResponseTypes"] = []string{"ReportBatchItemFailures"}
+		}
+		tmpl.Resources[fn.ID()+"EventSourceMapping"] = cfnResource{
+			Type:       "AWS::Lambda::EventSourceMapping",
+			Properties: mapping,
+		}
+	}
+}
+
+// cfnRole returns properties of execution role of fn
+func cfnRole(fn lambdaFunction) map[string]interface{} {
+	role := map[string]interface{}{
+		"AssumeRolePolicyDocument": map[string]interface{}{
+			"Version": "2012-10-17",
+			"Statement": []map[string]interface{}{{
+				"Effect":    "Allow",
+				"Principal": map[string]interface{}{"Service": []string{"lambda.amazonaws.com"}},
+				"Action":    []string{"sts:AssumeRole"},
+			}},
+		},
+		"ManagedPolicyArns": fn.managedPolicies(),
+	}
+
+	if len(fn.Method.Policies) > 0 {
+		statements := []map[string]interface{}{}
+		for _, policy := range fn.Method.Policies {
+			statements = append(statements, map[string]interface{}{
+				"Effect":   policy.Effect,
+				"Action":   policy.Actions,
+				"Resource": policy.Resources,
+			})
+		}
+		role["Policies"] = []map[string]interface{}{{
+			"PolicyName": fn.ID(),
+			"PolicyDocument": map[string]interface{}{
+				"Version":   "2012-10-17",
+				"Statement": statements,
+			},
+		}}
+	}
+	return role
+}
+
+// WriteCloudFormation writes CloudFormation template into file p
+func (svc *Service) WriteCloudFormation(p string) error {
+	data, err := svc.Config().CloudFormation()
+	if err != nil {
+		return err
+	}
+	if err := writeFile(p, string(data)); err != nil {
+		return err
+	}
+	svc.Logger().Info("generated cloudformation template", "path", p)
+	return nil
+}
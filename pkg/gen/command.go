@@ -1,15 +1,29 @@
 package gen
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"strings"
 )
 
+// Exec runs cmd in dir like Service.Exec, output is written into stdout
+// and stderr
 func Exec(dir string, cmd string, args ...string) (ran bool, err error) {
+	return (&Service{}).Exec(dir, cmd, args...)
+}
+
+// Exec runs cmd in dir, ${VAR} of cmd and args are expanded from
+// environment. Output is written into writer of svc, see SetOutput.
+func (svc *Service) Exec(dir string, cmd string, args ...string) (ran bool, err error) {
+	return svc.ExecContext(context.Background(), dir, cmd, args...)
+}
+
+// ExecContext runs cmd like Exec, canceling ctx kills the command
+func (svc *Service) ExecContext(ctx context.Context, dir string, cmd string, args ...string) (ran bool, err error) {
 
 	env := map[string]string{}
 
@@ -24,18 +38,25 @@ func Exec(dir string, cmd string, args ...string) (ran bool, err error) {
 	for i := range args {
 		args[i] = os.Expand(args[i], expand)
 	}
-	ran, code, err := run(env, dir, os.Stdout, os.Stderr, cmd, args...)
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if svc.w != nil {
+		stdout, stderr = svc.w, svc.w
+	}
+	ran, code, err := run(ctx, svc.Logger(), env, dir, stdout, stderr, cmd, args...)
 	if err == nil {
 		return true, nil
 	}
+	if ctx.Err() != nil {
+		return ran, ctx.Err()
+	}
 	if ran {
 		return ran, fmt.Errorf(`running "%s %s" failed with exit code %d`, cmd, strings.Join(args, " "), code)
 	}
 	return ran, fmt.Errorf(`failed to run "%s %s: %v"`, cmd, strings.Join(args, " "), err)
 }
 
-func run(env map[string]string, dir string, stdout, stderr io.Writer, cmd string, args ...string) (ran bool, code int, err error) {
-	c := exec.Command(cmd, args...)
+func run(ctx context.Context, logger *slog.Logger, env map[string]string, dir string, stdout, stderr io.Writer, cmd string, args ...string) (ran bool, code int, err error) {
+	c := exec.CommandContext(ctx, cmd, args...)
 	c.Dir = dir
 	c.Env = os.Environ()
 	for k, v := range env {
@@ -43,8 +64,7 @@ func run(env map[string]string, dir string, stdout, stderr io.Writer, cmd string
 	}
 	c.Stderr = stderr
 	c.Stdout = stdout
-	c.Stdin = os.Stdin
-	log.Println("exec:", cmd, strings.Join(args, " "))
+	logger.Debug("exec", "cmd", cmd, "args", strings.Join(args, " "))
 	err = c.Run()
 	return CmdRan(err), ExitStatus(err), err
 }
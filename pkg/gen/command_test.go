@@ -0,0 +1,31 @@
+package gen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExec(t *testing.T) {
+	var b bytes.Buffer
+	svc := &Service{}
+	svc.SetOutput(&b)
+	if _, err := svc.Exec(".", "go", "version"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "go version") {
+		t.Errorf("written %q, want go version", b.String())
+	}
+}
+
+func TestExecCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc := &Service{}
+	svc.SetOutput(&bytes.Buffer{})
+	if _, err := svc.ExecContext(ctx, ".", "go", "version"); !errors.Is(err, context.Canceled) {
+		t.Errorf("error %v, want %v", err, context.Canceled)
+	}
+}
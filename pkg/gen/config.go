@@ -1,10 +1,45 @@
 package gen
 
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
 type ConfigMethod struct {
 	Name    string `json:"name"`
 	S3Key   string `json:"s3Key"`
 	Handler string `json:"handler"`
 	Runtime string `json:"runtime"`
+	// Transport of command or query requests, see Route
+	Transport string `json:"transport,omitempty"`
+	// Source of mutation events
+	Source string `json:"source,omitempty"`
+	// ReportBatchItemFailures is set when handler reports partial batch
+	// failures, event source has to enable ReportBatchItemFailures
+	ReportBatchItemFailures bool `json:"reportBatchItemFailures,omitempty"`
+	Settings
+}
+
+// Settings of a deployed function
+type Settings struct {
+	MemorySize          int               `json:"memorySize,omitempty"`
+	Timeout             int               `json:"timeout,omitempty"`
+	ReservedConcurrency *int              `json:"reservedConcurrency,omitempty"`
+	Environment         map[string]string `json:"environment,omitempty"`
+	VPC                 *ConfigVPC        `json:"vpc,omitempty"`
+	Policies            []ConfigPolicy    `json:"policies,omitempty"`
+}
+
+type ConfigVPC struct {
+	SubnetIDs        []string `json:"subnetIds"`
+	SecurityGroupIDs []string `json:"securityGroupIds"`
+}
+
+type ConfigPolicy struct {
+	Effect    string   `json:"effect"`
+	Actions   []string `json:"actions"`
+	Resources []string `json:"resources"`
 }
 
 // Config -
@@ -28,3 +63,80 @@ type CDKContext struct {
 	Name   string `json:"name"`
 	Bucket string `json:"bucket"`
 }
+
+// lambdaFunction is a deployable function of Config
+type lambdaFunction struct {
+	Kind   AssetType
+	Method ConfigMethod
+}
+
+// ID returns alphanumeric identifier of the function unique within Config
+func (fn lambdaFunction) ID() string {
+	id := []rune{}
+	for _, r := range string(fn.Kind) + fn.Method.Name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			id = append(id, r)
+		}
+	}
+	return string(id)
+}
+
+// FunctionName returns deployed name of the function
+func (fn lambdaFunction) FunctionName(service string) string {
+	return fmt.Sprintf("%s-%s-%s", service, strings.ToLower(string(fn.Kind)), fn.Method.Name)
+}
+
+// Route returns route key of HTTP API serving command or query with HTTP
+// transport, e.g. POST /commands/Create, empty for other functions. The
+// route is called by the TypeScript client.
+func (fn lambdaFunction) Route() string {
+	if Transport(fn.Method.Transport) != HTTPTransport {
+		return ""
+	}
+	switch fn.Kind {
+	case CommandType:
+		return "POST /commands/" + fn.Method.Name
+	case QueryType:
+		return "POST /queries/" + fn.Method.Name
+	}
+	return ""
+}
+
+// source returns source of mutation events
+func (fn lambdaFunction) source() Source {
+	if fn.Method.Source == "" {
+		return KinesisSource
+	}
+	return Source(fn.Method.Source)
+}
+
+// managedPolicies returns AWS managed policies required by the function
+func (fn lambdaFunction) managedPolicies() []string {
+	result := []string{
+		"arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole",
+	}
+	if fn.Kind == MutationType && sources[fn.source()].policy != "" {
+		result = append(result, sources[fn.source()].policy)
+	}
+	if fn.Method.VPC != nil {
+		result = append(result, "arn:aws:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole")
+	}
+	return result
+}
+
+func (cfg *Config) lambdas() []lambdaFunction {
+	result := []lambdaFunction{}
+	for _, method := range cfg.Commands {
+		result = append(result, lambdaFunction{CommandType, method})
+	}
+	for _, method := range cfg.Queries {
+		result = append(result, lambdaFunction{QueryType, method})
+	}
+	for _, method := range cfg.Functions {
+		result = append(result, lambdaFunction{FunctionType, method})
+	}
+	if cfg.Mutation != nil {
+		result = append(result, lambdaFunction{MutationType, *cfg.Mutation})
+	}
+	return result
+}
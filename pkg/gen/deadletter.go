@@ -0,0 +1,99 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mrzahrada/gen/pkg/deadletter"
+)
+
+var deadLetterSinkType = reflect.TypeOf((*deadletter.Sink)(nil)).Elem()
+
+// DeadLetter configures quarantine of events failing in mutation
+type DeadLetter struct {
+	MaxAttempts int
+	MaxAge      time.Duration
+	Sink        DeadLetterSink
+}
+
+// Custom reports whether mutation itself implements deadletter.Sink
+func (d *DeadLetter) Custom() bool {
+	return d.Sink.kind == ""
+}
+
+// DeadLetterSink of quarantined events
+type DeadLetterSink struct {
+	kind   string
+	arn    string
+	prefix string
+}
+
+// SQSDeadLetter sends quarantined events to queue
+func SQSDeadLetter(queueArn string) DeadLetterSink {
+	return DeadLetterSink{kind: "sqs", arn: queueArn}
+}
+
+// S3DeadLetter stores quarantined events in bucket under prefix
+func S3DeadLetter(bucket, prefix string) DeadLetterSink {
+	return DeadLetterSink{kind: "s3", arn: "arn:aws:s3:::" + bucket, prefix: prefix}
+}
+
+// ServiceDeadLetter passes quarantined events to mutation implementing
+// deadletter.Sink
+func ServiceDeadLetter() DeadLetterSink {
+	return DeadLetterSink{}
+}
+
+// WithDeadLetter quarantines events of mutation into sink after
+// maxAttempts failed attempts or when older than maxAge. Zero disables
+// the limit.
+func WithDeadLetter(sink DeadLetterSink, maxAttempts int, maxAge time.Duration) Option {
+	return func(o *options) {
+		o.deadLetter = &DeadLetter{
+			MaxAttempts: maxAttempts,
+			MaxAge:      maxAge,
+			Sink:        sink,
+		}
+	}
+}
+
+// configure validates dead letter of mutation t and adds environment and
+// permissions required by the sink into settings
+func (d *DeadLetter) configure(t reflect.Type, settings *Settings) error {
+	if d.MaxAttempts <= 0 && d.MaxAge <= 0 {
+		return errors.New("dead letter requires max attempts or max age")
+	}
+
+	var target string
+	var permission MethodOption
+
+	switch d.Sink.kind {
+	case "":
+		if !t.Implements(deadLetterSinkType) {
+			return fmt.Errorf("%s does not implement deadletter.Sink", t)
+		}
+		return nil
+	case "sqs":
+		// arn:aws:sqs:region:account:name
+		parts := strings.Split(d.Sink.arn, ":")
+		if len(parts) != 6 || parts[2] != "sqs" {
+			return fmt.Errorf("invalid queue arn %q", d.Sink.arn)
+		}
+		target = fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/%s", parts[3], parts[4], parts[5])
+		permission = Allow([]string{"sqs:SendMessage"}, d.Sink.arn)
+	case "s3":
+		target = strings.TrimPrefix(d.Sink.arn, "arn:aws:s3:::") + "/" + d.Sink.prefix
+		permission = Allow([]string{"s3:PutObject"}, d.Sink.arn+"/"+d.Sink.prefix+"*")
+	}
+
+	if err := Env(deadletter.EnvSink, d.Sink.kind)(settings); err != nil {
+		return err
+	}
+	if err := Env(deadletter.EnvTarget, target)(settings); err != nil {
+		return err
+	}
+	return permission(settings)
+}
@@ -0,0 +1,409 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mrzahrada/gen/pkg/env"
+)
+
+const (
+	envPkg       = "github.com/mrzahrada/gen/pkg/env"
+	lifecyclePkg = "github.com/mrzahrada/gen/pkg/lifecycle"
+)
+
+// Factory constructs service in generated main
+type Factory struct {
+	pkg  string
+	name string
+	// err is set when factory returns error as the second result
+	err    bool
+	params []*dependency
+}
+
+// dependency is a factory parameter, created by factory or loaded from
+// environment variables
+type dependency struct {
+	factory *Factory
+	env     reflect.Type
+}
+
+var awsSession = &Factory{pkg: "github.com/aws/aws-sdk-go/aws/session", name: "NewSession", err: true}
+
+// builtins are factories of parameters by type key
+var builtins = map[string]*Factory{
+	"context.Context": {pkg: "context", name: "Background"},
+	"*github.com/aws/aws-sdk-go/aws/session.Session":      awsSession,
+	"github.com/aws/aws-sdk-go/aws/client.ConfigProvider": awsSession,
+	"*github.com/aws/aws-sdk-go/aws.Config":               {pkg: "github.com/aws/aws-sdk-go/aws", name: "NewConfig"},
+}
+
+// WithFactory constructs services using exported package level function
+// fn instead of New of the service package. Parameters of fn are
+// satisfied by providers, context.Context, AWS session or config and
+// structs with env tags loaded by package env.
+func WithFactory(fn interface{}) Option {
+	return func(o *options) {
+		o.factoryFunc = fn
+	}
+}
+
+// WithFactoryName constructs services using function name of the service
+// package instead of New
+func WithFactoryName(name string) Option {
+	return func(o *options) {
+		o.factoryName = name
+	}
+}
+
+// WithProvider satisfies factory parameters of the type returned by
+// exported package level function fn, e.g. a wiring function returning
+// configured client. Parameters of fn are satisfied the same way.
+func WithProvider(fn interface{}) Option {
+	return func(o *options) {
+		o.providers = append(o.providers, fn)
+	}
+}
+
+// factoryResolver resolves factory parameters
+type factoryResolver struct {
+	providers map[string]interface{}
+	resolved  map[string]*Factory
+	resolving map[string]bool
+}
+
+// factory returns validated factory of service t
+func (o *options) factory(t reflect.Type) (*Factory, error) {
+	r := &factoryResolver{
+		providers: map[string]interface{}{},
+		resolved:  map[string]*Factory{},
+		resolving: map[string]bool{},
+	}
+	for _, fn := range o.providers {
+		v := reflect.ValueOf(fn)
+		if v.Kind() != reflect.Func || v.Type().NumOut() == 0 {
+			return nil, fmt.Errorf("provider %T is not a function", fn)
+		}
+		r.providers[typeKey(v.Type().Out(0))] = fn
+	}
+
+	var f *Factory
+	var result string
+	switch {
+	case o.factoryFunc != nil && o.factoryName != "":
+		return nil, errors.New("factory and factory name are exclusive")
+	case o.factoryFunc != nil:
+		factory, out, err := r.fromFunc(o.factoryFunc)
+		if err != nil {
+			return nil, err
+		}
+		f, result = factory, typeKey(out)
+	default:
+		name := o.factoryName
+		if name == "" {
+			name = "New"
+		}
+		pkg := t.PkgPath()
+		if t.Kind() == reflect.Ptr {
+			pkg = t.Elem().PkgPath()
+		}
+		factory, out, err := r.fromName(pkg, name)
+		if err == errNoSource && o.factoryName == "" {
+			// sources are not available, New is assumed to return (service, error)
+			return &Factory{pkg: pkg, name: name, err: true}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		f, result = factory, out
+	}
+
+	if result != typeKey(t) && result != "*"+typeKey(t) {
+		return nil, fmt.Errorf("factory %s.%s returns %s, expected %s", f.pkg, f.name, result, typeKey(t))
+	}
+	return f, nil
+}
+
+// fromFunc returns factory of function fn and its result type
+func (r *factoryResolver) fromFunc(fn interface{}) (*Factory, reflect.Type, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, nil, fmt.Errorf("factory %T is not a function", fn)
+	}
+	pkg, name, ok := funcName(v)
+	full := pkg + "." + name
+	if !ok {
+		return nil, nil, fmt.Errorf("factory %s is not an exported package level function", full)
+	}
+
+	t := v.Type()
+	if t.IsVariadic() {
+		return nil, nil, fmt.Errorf("factory %s is variadic", full)
+	}
+	if t.NumOut() != 1 && (t.NumOut() != 2 || t.Out(1) != errorType) {
+		return nil, nil, fmt.Errorf("factory %s does not return (T) or (T, error)", full)
+	}
+	f := &Factory{pkg: pkg, name: name, err: t.NumOut() == 2}
+	for i := 0; i < t.NumIn(); i++ {
+		d, err := r.param(t.In(i))
+		if err != nil {
+			return nil, nil, fmt.Errorf("factory %s: %v", full, err)
+		}
+		f.params = append(f.params, d)
+	}
+	return f, t.Out(0), nil
+}
+
+// funcName returns package and name of function v, ok is set when it is
+// an exported package level function, which generated main can reference
+func funcName(v reflect.Value) (pkg, name string, ok bool) {
+	// full name is import/path.Name
+	full := runtime.FuncForPC(v.Pointer()).Name()
+	slash := strings.LastIndex(full, "/")
+	dot := strings.Index(full[slash+1:], ".") + slash + 1
+	pkg, name = full[:dot], full[dot+1:]
+	return pkg, name, pkg != "main" && ast.IsExported(name) && !strings.ContainsAny(name, ".[")
+}
+
+// param returns dependency satisfying parameter of type t
+func (r *factoryResolver) param(t reflect.Type) (*dependency, error) {
+	key := typeKey(t)
+	if fn, ok := r.providers[key]; ok {
+		if f, ok := r.resolved[key]; ok {
+			return &dependency{factory: f}, nil
+		}
+		if r.resolving[key] {
+			return nil, fmt.Errorf("provider of %s depends on itself", key)
+		}
+		r.resolving[key] = true
+		f, _, err := r.fromFunc(fn)
+		if err != nil {
+			return nil, err
+		}
+		r.resolved[key] = f
+		return &dependency{factory: f}, nil
+	}
+	if f, ok := builtins[key]; ok {
+		return &dependency{factory: f}, nil
+	}
+	if env.Tagged(t) {
+		if err := env.Check(t); err != nil {
+			return nil, err
+		}
+		return &dependency{env: t}, nil
+	}
+	return nil, fmt.Errorf("cannot satisfy parameter %s, add env tags or WithProvider", key)
+}
+
+// errNoSource is returned when sources of factory package are not found
+var errNoSource = errors.New("factory sources not found")
+
+// fromName returns factory of function name in package pkg and type key
+// of its result. Parameters are resolved from sources, so that only
+// providers and builtins are supported.
+func (r *factoryResolver) fromName(pkg, name string) (*Factory, string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, "", errNoSource
+	}
+	p, err := build.Import(pkg, wd, 0)
+	if err != nil {
+		return nil, "", errNoSource
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range p.GoFiles {
+		f, err := parser.ParseFile(fset, path.Join(p.Dir, file), nil, 0)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Name.Name != name {
+				continue
+			}
+			return r.fromDecl(pkg, fn, fileImports(f))
+		}
+	}
+	return nil, "", fmt.Errorf("factory %s.%s not found", pkg, name)
+}
+
+func (r *factoryResolver) fromDecl(pkg string, fn *ast.FuncDecl, imports map[string]string) (*Factory, string, error) {
+	full := pkg + "." + fn.Name.Name
+	if !ast.IsExported(fn.Name.Name) || fn.Type.TypeParams != nil {
+		return nil, "", fmt.Errorf("factory %s is not an exported package level function", full)
+	}
+
+	results := fn.Type.Results
+	if results == nil || results.NumFields() < 1 || results.NumFields() > 2 {
+		return nil, "", fmt.Errorf("factory %s does not return (T) or (T, error)", full)
+	}
+	if results.NumFields() == 2 {
+		last := results.List[len(results.List)-1].Type
+		if ident, ok := last.(*ast.Ident); !ok || ident.Name != "error" {
+			return nil, "", fmt.Errorf("factory %s does not return (T) or (T, error)", full)
+		}
+	}
+	result, ok := astTypeKey(results.List[0].Type, pkg, imports)
+	if !ok {
+		return nil, "", fmt.Errorf("factory %s returns unsupported type", full)
+	}
+
+	f := &Factory{pkg: pkg, name: fn.Name.Name, err: results.NumFields() == 2}
+	for _, field := range fn.Type.Params.List {
+		key, ok := astTypeKey(field.Type, pkg, imports)
+		if !ok {
+			return nil, "", fmt.Errorf("factory %s has unsupported parameter type", full)
+		}
+		d, err := r.paramKey(key)
+		if err != nil {
+			return nil, "", fmt.Errorf("factory %s: %v", full, err)
+		}
+		// a, b T declares two parameters
+		for i := 0; i < len(field.Names) || i == 0; i++ {
+			f.params = append(f.params, d)
+		}
+	}
+	return f, result, nil
+}
+
+// paramKey returns dependency of parameter declared in sources
+func (r *factoryResolver) paramKey(key string) (*dependency, error) {
+	if fn, ok := r.providers[key]; ok {
+		return r.param(reflect.ValueOf(fn).Type().Out(0))
+	}
+	if f, ok := builtins[key]; ok {
+		return &dependency{factory: f}, nil
+	}
+	return nil, fmt.Errorf("cannot satisfy parameter %s, use WithProvider or WithFactory", key)
+}
+
+// fileImports returns import paths by name used in file
+func fileImports(f *ast.File) map[string]string {
+	imports := map[string]string{}
+	for _, spec := range f.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := packageName(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = p
+	}
+	return imports
+}
+
+// astTypeKey returns type key of type expression declared in package pkg
+func astTypeKey(expr ast.Expr, pkg string, imports map[string]string) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		key, ok := astTypeKey(e.X, pkg, imports)
+		return "*" + key, ok
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok || imports[x.Name] == "" {
+			return "", false
+		}
+		return imports[x.Name] + "." + e.Sel.Name, true
+	case *ast.Ident:
+		if types.Universe.Lookup(e.Name) != nil {
+			return e.Name, true
+		}
+		return pkg + "." + e.Name, true
+	}
+	return "", false
+}
+
+// typeKey identifies type t by package path and name
+func typeKey(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		return "*" + typeKey(t.Elem())
+	}
+	if t.Name() != "" && t.PkgPath() != "" {
+		return t.PkgPath() + "." + t.Name()
+	}
+	return t.String()
+}
+
+// factoryCode generates statements constructing service
+type factoryCode struct {
+	im   *importer
+	b    strings.Builder
+	vars map[*Factory]string
+	n    int
+}
+
+// code returns statements declaring service as variable svc, errors of
+// factories are reported by lifecycle.Fail
+func (f *Factory) code(im *importer) string {
+	g := &factoryCode{
+		im:   im,
+		vars: map[*Factory]string{},
+	}
+	g.call(f, "svc", g.args(f))
+	return strings.TrimSpace(g.b.String())
+}
+
+// args declares parameters of f and returns their variables
+func (g *factoryCode) args(f *Factory) []string {
+	args := []string{}
+	for _, d := range f.params {
+		args = append(args, g.value(d))
+	}
+	return args
+}
+
+func (g *factoryCode) call(f *Factory, name string, args []string) {
+	fn := g.im.pkg(f.pkg) + "." + f.name
+	expr := fmt.Sprintf("%s(%s)", fn, strings.Join(args, ", "))
+	if f.err {
+		fmt.Fprintf(&g.b, "%s, err := %s\n\tif err != nil {\n\t\t%s\n\t}\n\t", name, expr, g.fail(fn, "err"))
+		return
+	}
+	fmt.Fprintf(&g.b, "%s := %s\n\t", name, expr)
+}
+
+func (g *factoryCode) value(d *dependency) string {
+	if d.env != nil {
+		name := g.next()
+		load := g.im.pkg(envPkg) + ".Load"
+		fail := g.fail(load+" "+g.im.typeName(d.env), "err")
+		if d.env.Kind() == reflect.Ptr {
+			fmt.Fprintf(&g.b, "%s := &%s{}\n\tif err := %s(%s); err != nil {\n\t\t%s\n\t}\n\t", name, g.im.typeName(d.env.Elem()), load, name, fail)
+		} else {
+			fmt.Fprintf(&g.b, "var %s %s\n\tif err := %s(&%s); err != nil {\n\t\t%s\n\t}\n\t", name, g.im.typeName(d.env), load, name, fail)
+		}
+		return name
+	}
+	if name, ok := g.vars[d.factory]; ok {
+		return name
+	}
+	args := g.args(d.factory)
+	name := g.next()
+	g.call(d.factory, name, args)
+	g.vars[d.factory] = name
+	return name
+}
+
+// fail returns statement reporting failed initialization step
+func (g *factoryCode) fail(step, err string) string {
+	return fmt.Sprintf("%s.Fail(%q, %s)", g.im.pkg(lifecyclePkg), step, err)
+}
+
+func (g *factoryCode) next() string {
+	g.n++
+	return fmt.Sprintf("dep%d", g.n)
+}
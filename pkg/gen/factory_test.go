@@ -0,0 +1,87 @@
+package gen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/factories"
+)
+
+const factoriesPkg = "github.com/mrzahrada/gen/pkg/gen/internal/fixture/factories"
+
+var factoriesType = reflect.TypeOf(&factories.Service{})
+
+// factoryOf returns factory of factories.Service configured by opts
+func factoryOf(opts ...Option) (*Factory, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o.factory(factoriesType)
+}
+
+func TestFactory(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		opts []Option
+		code []string
+	}{
+		{
+			name: "sources",
+			opts: []Option{WithProvider(factories.NewClient)},
+			code: []string{
+				"dep1 := context.Background()",
+				"var dep2 factories.Config",
+				"env.Load(&dep2)",
+				"dep3 := factories.NewClient(dep2)",
+				"svc, err := factories.New(dep1, dep3)",
+			},
+		},
+		{
+			name: "reflection",
+			opts: []Option{WithFactory(factories.NewFromConfig)},
+			code: []string{
+				"dep1 := &factories.Config{}",
+				"env.Load(dep1)",
+				"svc := factories.NewFromConfig(dep1)",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := factoryOf(test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			code := f.code(newImporter(nil, nil))
+			for _, line := range test.code {
+				if !strings.Contains(code, line) {
+					t.Errorf("missing %q in:\n%s", line, code)
+				}
+			}
+		})
+	}
+}
+
+func TestFactoryErrors(t *testing.T) {
+	for name, test := range map[string]struct {
+		opts []Option
+		err  string
+	}{
+		"unsupported env field":        {[]Option{WithFactory(factories.NewFromLimits)}, "unsupported type map[string]int"},
+		"generic":                      {[]Option{WithFactoryName("NewGeneric")}, "is not an exported package level function"},
+		"unsatisfied source parameter": {[]Option{WithFactoryName("NewFromCount")}, "cannot satisfy parameter int"},
+		"missing provider":             {nil, "cannot satisfy parameter *" + factoriesPkg + ".Client"},
+		"unknown name":                 {[]Option{WithFactoryName("NewMissing")}, "not found"},
+		"result":                       {[]Option{WithFactory(factories.NewOther)}, "returns *" + factoriesPkg + ".Client"},
+		"not a function":               {[]Option{WithFactory(factories.Config{})}, "is not a function"},
+		"exclusive":                    {[]Option{WithFactory(factories.NewFromConfig), WithFactoryName("New")}, "exclusive"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := factoryOf(test.opts...)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package gen
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/commands"
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/projector"
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/queries"
+)
+
+var update = flag.Bool("update", false, "update testdata/*.golden files")
+
+// golden compares got with testdata/name.golden
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	goldenFile(t, filepath.Join("testdata", name+".golden"), got)
+}
+
+// goldenFile compares got with file path, the file is rewritten with
+// -update
+func goldenFile(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := ioutil.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s differs, run go test -update when intended:\n%s", path, got)
+	}
+}
+
+// newGoldenService returns service with fixture commands and queries
+// routed by HTTP API and the projector mutation
+func newGoldenService(t *testing.T) *Service {
+	t.Helper()
+	svc := newTestService(t)
+	if err := svc.AddCommands(&commands.Service{}, WithTransport(HTTPTransport), WithMethodOptions("Create", Memory(256))); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddQueries(&queries.Service{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddMutation(&projector.Projector{}, WithOptions(Env("TABLE", "items"))); err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestGoldenMains(t *testing.T) {
+	svc := newGoldenService(t)
+	assets := map[string]Asset{"mutation": svc.Mutation}
+	for _, command := range svc.Commands {
+		switch command.Name() {
+		case "Create":
+			assets["command"] = command
+		case "Delete":
+			assets["command_pointer"] = command
+		}
+	}
+	for _, query := range svc.Queries {
+		if query.Name() == "List" {
+			assets["query"] = query
+		}
+	}
+	for name, asset := range assets {
+		tmpl, err := getTemplate(asset.Type())
+		if err != nil {
+			t.Fatal(err)
+		}
+		src, err := generate(tmpl, asset)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		golden(t, name+".go", []byte(src))
+	}
+}
+
+func TestGoldenCDK(t *testing.T) {
+	cdk, err := newGoldenService(t).CDK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "cdk.ts", []byte(cdk))
+}
+
+func TestGoldenCloudFormation(t *testing.T) {
+	cfn, err := newGoldenService(t).Config().CloudFormation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "cloudformation.json", cfn)
+}
+
+func TestGoldenTerraform(t *testing.T) {
+	tf, err := newGoldenService(t).Config().Terraform()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "terraform.tf.json", tf)
+}
+
+func TestGoldenMutationTest(t *testing.T) {
+	svc := newTestService(t)
+	err := svc.AddMutation(&projector.Projector{},
+		WithSource(SQSSource),
+		WithPush(PushEvery(2)),
+		WithEventNames("OnCreated", "ItemCreated"),
+		WithEventNames("OnDeleted", "Deleted", "Removed"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := svc.MutationTest("projectortest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the golden file is the generated package run by its own tests
+	goldenFile(t, filepath.Join("internal", "fixture", "projectortest", "mutation.go"), []byte(src))
+}
@@ -0,0 +1,107 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// WithEventNames maps event type names to event handler method of
+// mutation. Mapped handler does not have to be named after its event, so
+// that events with the same type name from different packages or renamed
+// events can be handled.
+func WithEventNames(method string, names ...string) Option {
+	return func(o *options) {
+		if o.eventNames == nil {
+			o.eventNames = map[string][]string{}
+		}
+		o.eventNames[method] = append(o.eventNames[method], names...)
+	}
+}
+
+// WithStrictHandlers fails AddMutation for On* methods which are not event
+// handlers. They are reported as warnings by default.
+func WithStrictHandlers() Option {
+	return func(o *options) {
+		o.strictHandlers = true
+	}
+}
+
+// handlerError returns why method is not an event handler with signature
+// On<Event>(context.Context, *Event) error or
+// On<Event>(context.Context, source.Envelope, *Event) error. Handler
+// which is mapped does not have to be named after its event.
+func handlerError(method reflect.Method, mapped bool) error {
+	if !strings.HasPrefix(method.Name, "On") || len(method.Name) <= 2 {
+		return errors.New("name does not start with On")
+	}
+
+	// first input is the receiver
+	in := method.Type.NumIn()
+	if in != 3 && in != 4 {
+		return fmt.Errorf("has %d inputs, expected (context.Context, [source.Envelope,] *Event)", in-1)
+	}
+	if !isContext(method.Type.In(1)) {
+		return fmt.Errorf("first input is %s, expected context.Context", method.Type.In(1))
+	}
+	if in == 4 && method.Type.In(2) != envelopeType {
+		return fmt.Errorf("second input is %s, expected source.Envelope", method.Type.In(2))
+	}
+	if method.Type.NumOut() != 1 || method.Type.Out(0) != errorType {
+		return errors.New("does not return only error")
+	}
+
+	event := method.Type.In(in - 1)
+	if event.Kind() == reflect.Ptr {
+		event = event.Elem()
+	}
+	if event.Kind() != reflect.Struct || event.Name() == "" {
+		return fmt.Errorf("event %s is not a named struct", event)
+	}
+	if !mapped && method.Name[2:] != event.Name() {
+		return fmt.Errorf("name does not match event %s, rename it to On%s or map it using WithEventNames", event, event.Name())
+	}
+	return nil
+}
+
+// addHandlers adds event handlers of t into mutation m. On* methods which
+// are not event handlers are reported, hooks are skipped.
+func addHandlers(m *Mutation, t reflect.Type, o *options, logger *slog.Logger) error {
+	mapped := map[string]bool{}
+	for i := 0; i < t.NumMethod(); i++ {
+		method := t.Method(i)
+		if !strings.HasPrefix(method.Name, "On") {
+			continue
+		}
+		if method.Name == "OnUnknown" && m.Unknown == UnknownRoute {
+			continue
+		}
+		names, ok := o.eventNames[method.Name]
+		if err := handlerError(method, ok); err != nil {
+			if ok || o.strictHandlers {
+				return fmt.Errorf("%s.%s is not an event handler: %v", t, method.Name, err)
+			}
+			logger.Warn("method is not an event handler", "method", t.String()+"."+method.Name, "error", err)
+			continue
+		}
+		mapped[method.Name] = true
+		if err := m.Add(method, names...); err != nil {
+			return err
+		}
+		logger.Debug("added event handler", "method", t.String()+"."+method.Name, "event", method.Type.In(method.Type.NumIn()-1))
+	}
+
+	for method := range o.eventNames {
+		if !mapped[method] {
+			return fmt.Errorf("event names mapped to unknown handler %s.%s", t, method)
+		}
+		for _, name := range o.eventNames[method] {
+			if name == "" {
+				return fmt.Errorf("empty event name mapped to %s.%s", t, method)
+			}
+		}
+	}
+	return nil
+}
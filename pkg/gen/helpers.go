@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"go/format"
 	"io"
 	"io/ioutil"
 	"os"
@@ -27,7 +28,6 @@ func findConfig() (*CDKConfig, string, error) {
 				return err
 			}
 			if info.Name() == "cdk.json" {
-				fmt.Println("found:", p)
 				configPath = p
 				return io.EOF
 			}
@@ -36,18 +36,22 @@ func findConfig() (*CDKConfig, string, error) {
 	if err != io.EOF && err != nil {
 		return nil, "", err
 	}
+	if configPath == "" {
+		return nil, "", errors.New("cdk.json not found")
+	}
 
-	jsonFile, err := os.Open(configPath)
+	byteValue, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		return nil, "", err
 	}
-	byteValue, _ := ioutil.ReadAll(jsonFile)
 	result := &CDKConfig{}
-	err = json.Unmarshal(byteValue, result)
+	if err := json.Unmarshal(byteValue, result); err != nil {
+		return nil, "", fmt.Errorf("%s: %v", configPath, err)
+	}
 
 	configDir := path.Dir(configPath)
 
-	return result, configDir, err
+	return result, configDir, nil
 }
 
 func fmtDuration(d time.Duration) string {
@@ -62,53 +66,46 @@ func isContext(input reflect.Type) bool {
 	return input.Implements(reflect.TypeOf((*context.Context)(nil)).Elem())
 }
 
-func openFile(file string) (*os.File, error) {
-
-	dir := path.Dir(file)
-
-	if _, err := os.Stat(file); os.IsNotExist(err) {
-		if err := os.MkdirAll(dir, 0700); err != nil {
-			return nil, err
-		}
-		return os.Create(file)
-	} else if err != nil {
-		return nil, err
+// writeFile creates file p with content, including missing directories
+func writeFile(p, content string) error {
+	if err := os.MkdirAll(path.Dir(p), 0755); err != nil {
+		return err
 	}
-
-	return os.OpenFile(file, os.O_WRONLY, 0644)
+	return ioutil.WriteFile(p, []byte(content), 0644)
 }
 
-func buildMain(dir, key, content string) (string, error) {
+// buildMain compiles content into zipped binary in dir and returns path of
+// the zip. Canceling ctx kills the compiler.
+func buildMain(ctx context.Context, dir, key, content string) (string, error) {
 	pkgDir := path.Join(dir, "assets", key)
 	mainPath := path.Join(pkgDir, "main.go")
 	binPath := path.Join(pkgDir, "main.out")
 
 	// 1. write contect to main.go file
-	f, err := openFile(mainPath)
-	if err != nil {
-		return "", err
-	}
-
-	if _, err := f.WriteString(content); err != nil {
+	if err := writeFile(mainPath, content); err != nil {
 		return "", err
 	}
 
 	// 2. compile main.go file to main.out
-	cmd := exec.Command("go", "build", "-ldflags", "-s -w", "-o", "main.out")
+	cmd := exec.CommandContext(ctx, "go", "build", "-ldflags", "-s -w", "-o", "main.out")
 	cmd.Dir = pkgDir
 	envs := append(os.Environ(), "GOOS=linux", "GOARCH=amd64", "GOBIN="+pkgDir)
 	cmd.Env = envs
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 	if err := cmd.Run(); err != nil {
-		fmt.Println(cmd)
-		fmt.Println(content)
-		fmt.Println(fmt.Sprint(err) + ": " + stderr.String())
-		return "", err
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("%s: %v: %s", cmd, err, strings.TrimSpace(stderr.String()))
 	}
 
 	// 3. zip result. zip name is a sha1 hash of the binary
-	zipPath := path.Join(dir, "assets", filehash(binPath)) + ".zip"
+	hash, err := filehash(binPath)
+	if err != nil {
+		return "", err
+	}
+	zipPath := path.Join(dir, "assets", hash) + ".zip"
 
 	if err := zipFile(zipPath, binPath); err != nil {
 		return "", err
@@ -118,11 +115,26 @@ func buildMain(dir, key, content string) (string, error) {
 }
 
 func generate(tmpl *template.Template, asset Asset) (string, error) {
+	return execute(tmpl, asset, fixedImports[asset.Type()])
+}
+
+// execute executes tmpl with types referenced through importer and
+// formats the result
+func execute(tmpl *template.Template, data interface{}, fixed map[string]string) (string, error) {
+	im := newImporter(fixed, reservedNames)
 	var b strings.Builder
-	if err := tmpl.Execute(&b, asset); err != nil {
+	if err := tmpl.Funcs(im.funcs()).Execute(&b, data); err != nil {
 		return "", err
 	}
-	return b.String(), nil
+	if im.err != nil {
+		return "", fmt.Errorf("%s: %v", tmpl.Name(), im.err)
+	}
+	src := strings.Replace(b.String(), importsMarker, im.imports(), -1)
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return src, fmt.Errorf("%s: %v", tmpl.Name(), err)
+	}
+	return string(formatted), nil
 }
 
 func getTemplate(t AssetType) (*template.Template, error) {
@@ -135,11 +147,11 @@ func getTemplate(t AssetType) (*template.Template, error) {
 	case QueryType:
 		tmpl = resolverTmpl
 	case MutationType:
-		tmpl = mutationTmpl
+		tmpl = mutationTmpl + mutationDispatchTmpl
 	case FunctionType:
 		return nil, errors.New("not implemented")
 	default:
 		return nil, errors.New("not implemented")
 	}
-	return template.New(string(t)).Parse(tmpl)
+	return template.New(string(t)).Funcs(newImporter(nil, nil).funcs()).Parse(tmpl)
 }
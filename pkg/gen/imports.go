@@ -0,0 +1,341 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"os"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// importsMarker is replaced by imports of generated file once all types
+// are referenced
+const importsMarker = "/* imports */"
+
+// fixedImports are imported by templates themselves, by path
+var fixedImports = map[AssetType]map[string]string{
+	CommandType: {"encoding/json": "json", "github.com/aws/aws-lambda-go/lambda": "lambda"},
+	QueryType:   {"encoding/json": "json", "github.com/aws/aws-lambda-go/lambda": "lambda"},
+	MutationType: {
+		"context":                                   "context",
+		"github.com/mrzahrada/gen/pkg/source":       "source",
+		"github.com/mrzahrada/es":                   "es",
+		"github.com/aws/aws-lambda-go/events":       "lambdaevents",
+		"github.com/aws/aws-lambda-go/lambda":       "lambda",
+		"github.com/mrzahrada/gen/pkg/mutationtest": "mutationtest",
+	},
+}
+
+// reservedNames are conditional imports and identifiers of templates,
+// imported packages with these names are aliased
+var reservedNames = []string{
+	"json", "fmt", "time", "deadletter", "checkpoint",
+	"main", "handler", "service", "recorder",
+	"svc", "h", "r", "v", "i", "ok", "ctx", "err", "input", "event", "record", "records",
+	"response", "failed", "failure", "result", "sink", "batch", "checkpoints", "positions",
+	"pending", "processed", "letter", "attempts", "cause", "entry", "sequence", "shard",
+	"payload", "provider", "span", "id", "key",
+	"from", "offset", "size", "stop", "reader", "encoder", "eof", "done", "last", "data", "eventName",
+}
+
+// packageNames caches names of imported packages by path, services may
+// generate code concurrently
+var (
+	packageNamesMu sync.Mutex
+	packageNames   = map[string]string{}
+)
+
+// packageName returns name declared by package path, which may differ
+// from its directory
+func packageName(p string) string {
+	packageNamesMu.Lock()
+	name, ok := packageNames[p]
+	packageNamesMu.Unlock()
+	if ok {
+		return name
+	}
+	name = lookupPackageName(p)
+	packageNamesMu.Lock()
+	packageNames[p] = name
+	packageNamesMu.Unlock()
+	return name
+}
+
+// lookupPackageName returns name of package p found in build context,
+// name derived from its path otherwise
+func lookupPackageName(p string) string {
+	name := ""
+	if wd, err := os.Getwd(); err == nil {
+		if pkg, err := build.Import(p, wd, 0); err == nil {
+			name = pkg.Name
+		}
+	}
+	if name == "" {
+		name = path.Base(p)
+		// major version suffix is not part of package name
+		if strings.HasPrefix(name, "v") && strings.Trim(name[1:], "0123456789") == "" && p != name {
+			name = path.Base(path.Dir(p))
+		}
+		name = strings.Map(func(r rune) rune {
+			if r == '-' || r == '.' {
+				return '_'
+			}
+			return r
+		}, name)
+	}
+	return name
+}
+
+// importer assigns unique names to packages imported by generated file
+type importer struct {
+	fixed map[string]string
+	names map[string]string
+	taken map[string]bool
+	// err is set when a type cannot be referenced
+	err error
+}
+
+// newImporter returns importer of file, which imports fixed packages
+// itself and declares reserved identifiers
+func newImporter(fixed map[string]string, reserved []string) *importer {
+	im := &importer{
+		fixed: fixed,
+		names: map[string]string{},
+		taken: map[string]bool{},
+	}
+	for _, name := range fixed {
+		im.taken[name] = true
+	}
+	for _, name := range reserved {
+		im.taken[name] = true
+	}
+	return im
+}
+
+// pkg returns name of package path in generated file
+func (im *importer) pkg(p string) string {
+	// vendored packages are imported without vendor prefix
+	if i := strings.LastIndex(p, "/vendor/"); i >= 0 {
+		p = p[i+len("/vendor/"):]
+	}
+	if name, ok := im.fixed[p]; ok {
+		return name
+	}
+	if name, ok := im.names[p]; ok {
+		return name
+	}
+	base := packageName(p)
+	name := base
+	for i := 2; im.taken[name]; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	im.taken[name] = true
+	im.names[p] = name
+	return name
+}
+
+// typeName returns reference of t in generated file. Types are printed
+// recursively, so that packages of element, field and type argument types
+// are imported as well.
+func (im *importer) typeName(t reflect.Type) string {
+	if t.Name() != "" {
+		return im.namedType(t)
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + im.typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + im.typeName(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), im.typeName(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", im.typeName(t.Key()), im.typeName(t.Elem()))
+	case reflect.Chan:
+		return im.chanType(t)
+	case reflect.Func:
+		return "func" + im.funcType(t, 0)
+	case reflect.Struct:
+		return im.structType(t)
+	case reflect.Interface:
+		return im.interfaceType(t)
+	}
+	return t.String()
+}
+
+// qualifiedIdent matches package qualified identifier in type arguments
+// of generic type name, e.g. github.com/a/b.Type
+var qualifiedIdent = regexp.MustCompile(`([\w\-~./]+)\.([\pL_][\pL\pN_]*)`)
+
+func (im *importer) namedType(t reflect.Type) string {
+	if t.Kind() == reflect.UnsafePointer {
+		return im.pkg("unsafe") + ".Pointer"
+	}
+	if t.PkgPath() == "" {
+		// predeclared type
+		return t.Name()
+	}
+	name := t.Name()
+	if !ast.IsExported(name) {
+		im.fail(fmt.Errorf("type %s is not exported", t))
+	}
+	// type arguments of generic types are printed with package paths
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i] + qualifiedIdent.ReplaceAllStringFunc(name[i:], func(ident string) string {
+			match := qualifiedIdent.FindStringSubmatch(ident)
+			return im.pkg(match[1]) + "." + match[2]
+		})
+	}
+	return im.pkg(t.PkgPath()) + "." + name
+}
+
+func (im *importer) chanType(t reflect.Type) string {
+	elem := im.typeName(t.Elem())
+	switch t.ChanDir() {
+	case reflect.RecvDir:
+		return "<-chan " + elem
+	case reflect.SendDir:
+		return "chan<- " + elem
+	}
+	// chan of receive only channel has to be parenthesized
+	if t.Elem().Kind() == reflect.Chan && t.Elem().Name() == "" && t.Elem().ChanDir() == reflect.RecvDir {
+		elem = "(" + elem + ")"
+	}
+	return "chan " + elem
+}
+
+// funcType returns parameters and results of function type t, skip is
+// number of leading parameters left out, e.g. receiver
+func (im *importer) funcType(t reflect.Type, skip int) string {
+	inputs := []string{}
+	for i := skip; i < t.NumIn(); i++ {
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			inputs = append(inputs, "..."+im.typeName(t.In(i).Elem()))
+			continue
+		}
+		inputs = append(inputs, im.typeName(t.In(i)))
+	}
+	outputs := []string{}
+	for i := 0; i < t.NumOut(); i++ {
+		outputs = append(outputs, im.typeName(t.Out(i)))
+	}
+	result := "(" + strings.Join(inputs, ", ") + ")"
+	switch len(outputs) {
+	case 0:
+		return result
+	case 1:
+		return result + " " + outputs[0]
+	}
+	return result + " (" + strings.Join(outputs, ", ") + ")"
+}
+
+func (im *importer) structType(t reflect.Type) string {
+	if t.NumField() == 0 {
+		return "struct{}"
+	}
+	fields := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			im.fail(fmt.Errorf("field %s of %s is not exported", f.Name, t))
+		}
+		field := im.typeName(f.Type)
+		if !f.Anonymous {
+			field = f.Name + " " + field
+		}
+		if f.Tag != "" && strings.Contains(string(f.Tag), "`") {
+			field += " " + strconv.Quote(string(f.Tag))
+		} else if f.Tag != "" {
+			field += " `" + string(f.Tag) + "`"
+		}
+		fields = append(fields, field)
+	}
+	return "struct{ " + strings.Join(fields, "; ") + " }"
+}
+
+func (im *importer) interfaceType(t reflect.Type) string {
+	if t.NumMethod() == 0 {
+		return "interface{}"
+	}
+	methods := []string{}
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		if m.PkgPath != "" {
+			im.fail(fmt.Errorf("method %s of %s is not exported", m.Name, t))
+		}
+		methods = append(methods, m.Name+im.funcType(m.Type, 0))
+	}
+	return "interface{ " + strings.Join(methods, "; ") + " }"
+}
+
+// fail records the first error of printed types
+func (im *importer) fail(err error) {
+	if im.err == nil {
+		im.err = err
+	}
+}
+
+// signature returns function type of method without receiver
+func (im *importer) signature(m interface {
+	Inputs() []reflect.Type
+	Outputs() []reflect.Type
+}) string {
+	inputs := []string{}
+	for _, in := range m.Inputs() {
+		inputs = append(inputs, im.typeName(in))
+	}
+	outputs := []string{}
+	for _, out := range m.Outputs() {
+		outputs = append(outputs, im.typeName(out))
+	}
+	return fmt.Sprintf("func(%s) (%s)", strings.Join(inputs, ", "), strings.Join(outputs, ", "))
+}
+
+// paths returns sorted paths of referenced packages
+func (im *importer) paths() []string {
+	paths := []string{}
+	for p := range im.names {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// imports returns import specs of referenced packages, alias is written
+// only when name differs from the path
+func (im *importer) imports() string {
+	var b strings.Builder
+	for _, p := range im.paths() {
+		name := im.names[p]
+		if name == path.Base(p) {
+			fmt.Fprintf(&b, "\n\t%q", p)
+			continue
+		}
+		fmt.Fprintf(&b, "\n\t%s %q", name, p)
+	}
+	return b.String()
+}
+
+// funcs returns template functions referencing types through im
+func (im *importer) funcs() template.FuncMap {
+	return template.FuncMap{
+		"imports":   func() string { return importsMarker },
+		"pkg":       im.pkg,
+		"typeName":  im.typeName,
+		"signature": im.signature,
+		"factory":   func(f *Factory) string { return f.code(im) },
+		// elemName returns name of pointed type
+		"elemName": func(t reflect.Type) string {
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			return im.typeName(t)
+		},
+	}
+}
@@ -0,0 +1,113 @@
+package gen
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/events"
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/generics"
+	otherevents "github.com/mrzahrada/gen/pkg/gen/internal/fixture/generics/events"
+)
+
+func TestPackageNameConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newImporter(nil, nil).pkg("github.com/mrzahrada/gen/pkg/gen/internal/fixture/events")
+			packageName("example.com/concurrent/v2")
+		}()
+	}
+	wg.Wait()
+	if name := packageName("example.com/concurrent/v2"); name != "concurrent" {
+		t.Errorf("package name %q, want concurrent", name)
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	const (
+		eventsPkg   = "github.com/mrzahrada/gen/pkg/gen/internal/fixture/events"
+		genericsPkg = "github.com/mrzahrada/gen/pkg/gen/internal/fixture/generics"
+		otherPkg    = genericsPkg + "/events"
+	)
+	for _, test := range []struct {
+		name     string
+		value    interface{}
+		reserved []string
+		want     string
+		paths    []string
+	}{
+		{
+			name:  "type argument from another package",
+			value: generics.Page[events.Created]{},
+			want:  "generics.Page[events.Created]",
+			paths: []string{eventsPkg, genericsPkg},
+		},
+		{
+			name:  "nested generics",
+			value: generics.Page[generics.Pair[string, *events.Created]]{},
+			want:  "generics.Page[generics.Pair[string,*events.Created]]",
+			paths: []string{eventsPkg, genericsPkg},
+		},
+		{
+			name:  "chan of receive only chan",
+			value: make(chan (<-chan events.Created)),
+			want:  "chan (<-chan events.Created)",
+			paths: []string{eventsPkg},
+		},
+		{
+			name:  "receive only chan of chan",
+			value: make(<-chan chan int),
+			want:  "<-chan chan int",
+		},
+		{
+			name: "anonymous struct with backquoted tag",
+			value: struct {
+				ID   string `json:"id"`
+				Note string "doc:\"`note`\""
+			}{},
+			want: "struct{ ID string `json:\"id\"`; Note string \"doc:\\\"`note`\\\"\" }",
+		},
+		{
+			name: "package name collision",
+			value: struct {
+				Created      events.Created
+				OtherCreated otherevents.Created
+			}{},
+			want:  "struct{ Created events.Created; OtherCreated events2.Created }",
+			paths: []string{eventsPkg, otherPkg},
+		},
+		{
+			name:     "reserved package name",
+			value:    []*events.Created{},
+			reserved: []string{"events"},
+			want:     "[]*events2.Created",
+			paths:    []string{eventsPkg},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			im := newImporter(nil, test.reserved)
+			if got := im.typeName(reflect.TypeOf(test.value)); got != test.want {
+				t.Errorf("type name %s, want %s", got, test.want)
+			}
+			if im.err != nil {
+				t.Error(im.err)
+			}
+			if got := im.paths(); !reflect.DeepEqual(got, test.paths) && len(got)+len(test.paths) > 0 {
+				t.Errorf("imported %v, want %v", got, test.paths)
+			}
+		})
+	}
+}
+
+func TestVendoredPackage(t *testing.T) {
+	im := newImporter(nil, nil)
+	if name := im.pkg("example.com/app/vendor/github.com/pkg/errors"); name != "errors" {
+		t.Errorf("package name %q, want errors", name)
+	}
+	if got := im.paths(); len(got) != 1 || got[0] != "github.com/pkg/errors" {
+		t.Errorf("imported %v, want github.com/pkg/errors", got)
+	}
+}
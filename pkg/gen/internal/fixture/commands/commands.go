@@ -0,0 +1,64 @@
+// Package commands is a command service used by tests of gen
+package commands
+
+import (
+	"context"
+	"errors"
+)
+
+type Service struct{}
+
+func New() (*Service, error) {
+	return &Service{}, nil
+}
+
+func (svc *Service) Create(ctx context.Context, input CreateInput) (*Item, error) {
+	return &Item{ID: input.Name}, nil
+}
+
+func (svc *Service) Get(ctx context.Context, input GetInput) (*Item, error) {
+	return &Item{ID: input.ID}, nil
+}
+
+func (svc *Service) Delete(ctx context.Context, input *DeleteInput) error {
+	return nil
+}
+
+func (svc *Service) Call(ctx context.Context) error {
+	return nil
+}
+
+type CreateInput struct {
+	Name string  `json:"name"`
+	Hash [4]byte `json:"hash"`
+	Meta
+	Hidden `json:"-"`
+}
+
+type Meta struct {
+	Owner string `json:"owner"`
+}
+
+type Hidden struct {
+	Secret string
+}
+
+type GetInput struct {
+	ID string `json:"id"`
+}
+
+type DeleteInput struct {
+	ID string `json:"id"`
+}
+
+func (input *DeleteInput) Validate() error {
+	if input.ID == "" {
+		return errors.New("missing id")
+	}
+	return nil
+}
+
+type Item struct {
+	ID   string `json:"id"`
+	Data []byte `json:"data,omitempty"`
+}
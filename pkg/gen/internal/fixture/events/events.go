@@ -0,0 +1,11 @@
+// Package events are events of mutations used by tests of gen
+package events
+
+type Created struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Deleted struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,56 @@
+// Package factories is a service with factories used by tests of gen
+package factories
+
+import (
+	"context"
+	"time"
+)
+
+// Config is loaded from environment variables
+type Config struct {
+	Table   string        `env:"TABLE,required"`
+	Timeout time.Duration `env:"TIMEOUT"`
+	Tags    []string      `env:"TAGS"`
+}
+
+// Limits has field of type which cannot be loaded from environment
+type Limits struct {
+	Limits map[string]int `env:"LIMITS"`
+}
+
+type Client struct {
+	Table string
+}
+
+type Service struct {
+	client *Client
+}
+
+// New is resolved from sources, client is satisfied by provider
+func New(ctx context.Context, client *Client) (*Service, error) {
+	return &Service{client: client}, nil
+}
+
+func NewClient(cfg Config) *Client {
+	return &Client{Table: cfg.Table}
+}
+
+func NewFromConfig(cfg *Config) *Service {
+	return &Service{client: NewClient(*cfg)}
+}
+
+func NewFromLimits(limits Limits) *Service {
+	return &Service{}
+}
+
+func NewFromCount(n int) *Service {
+	return &Service{}
+}
+
+func NewGeneric[T any]() *Service {
+	return &Service{}
+}
+
+func NewOther() *Client {
+	return &Client{}
+}
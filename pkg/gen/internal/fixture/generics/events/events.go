@@ -0,0 +1,7 @@
+// Package events has the name of package of fixture events, used by tests
+// of gen
+package events
+
+type Created struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,11 @@
+// Package generics has generic types used by tests of gen
+package generics
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+}
+
+type Pair[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
@@ -0,0 +1,51 @@
+// Package projector is a mutation used by tests of gen
+package projector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/events"
+)
+
+const (
+	// EnvFail is environment variable with ID of events failed by
+	// projector created by New
+	EnvFail = "PROJECTOR_FAIL"
+	// EnvFailPush fails every Push of projector created by New when set
+	EnvFailPush = "PROJECTOR_FAIL_PUSH"
+)
+
+// Projector fails events with ID Fail and every Push when FailPush is set
+type Projector struct {
+	Fail     string
+	FailPush bool
+}
+
+func New() (*Projector, error) {
+	return &Projector{Fail: os.Getenv(EnvFail), FailPush: os.Getenv(EnvFailPush) != ""}, nil
+}
+
+func (p *Projector) OnCreated(ctx context.Context, event *events.Created) error {
+	return p.handle(event.ID)
+}
+
+func (p *Projector) OnDeleted(ctx context.Context, event events.Deleted) error {
+	return p.handle(event.ID)
+}
+
+func (p *Projector) Push(ctx context.Context) error {
+	if p.FailPush {
+		return errors.New("push failed")
+	}
+	return nil
+}
+
+func (p *Projector) handle(id string) error {
+	if id != "" && id == p.Fail {
+		return fmt.Errorf("event %s failed", id)
+	}
+	return nil
+}
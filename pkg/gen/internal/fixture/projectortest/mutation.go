@@ -0,0 +1,175 @@
+// DO NOT EDIT! Generated code.
+
+// Package projectortest runs events through the generated handler of
+// *projector.Projector.
+package projectortest
+
+import (
+	"context"
+	lambdaevents "github.com/aws/aws-lambda-go/events"
+	"github.com/mrzahrada/es"
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/events"
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/projector"
+	"github.com/mrzahrada/gen/pkg/logging"
+	"github.com/mrzahrada/gen/pkg/middleware"
+	"github.com/mrzahrada/gen/pkg/mutationtest"
+	"github.com/mrzahrada/gen/pkg/source"
+)
+
+// recorder counts Push calls of the mutation
+type recorder struct {
+	service
+	pushes int
+}
+
+func (r *recorder) Push(ctx context.Context) error {
+	r.pushes++
+	return r.service.Push(ctx)
+}
+
+// eventName returns event type name registered for event, the first one
+// when the handler is mapped to several names
+func eventName(event interface{}) string {
+	switch event.(type) {
+	case events.Created, *events.Created:
+		return "ItemCreated"
+	case events.Deleted, *events.Deleted:
+		return "Deleted"
+	}
+	return ""
+}
+
+// Run passes input events to svc through the generated handler as
+// SQSEvent. Events are passed in a single batch.
+// Events are marshaled with event type names registered by the mutation,
+// mutationtest.Event sets the name explicitly.
+func Run(ctx context.Context, svc *projector.Projector, input ...interface{}) (mutationtest.Result, error) {
+	result := mutationtest.Result{Failed: -1}
+	records, err := mutationtest.MarshalNamed(eventName, input...)
+	if err != nil {
+		return result, err
+	}
+
+	r := &recorder{service: svc}
+	h := handler{
+		svc: r,
+		es: source.NewUnmarshaler().
+			Register("ItemCreated", events.Created{}).
+			Register("Deleted", events.Deleted{}).
+			Register("Removed", events.Deleted{}),
+	}
+
+	response, err := h.on(ctx, mutationtest.SQS(records...))
+	for _, failure := range response.BatchItemFailures {
+		for i, record := range records {
+			if record.ID == failure.ItemIdentifier {
+				result.Retried = append(result.Retried, i)
+			}
+		}
+	}
+	if len(result.Retried) > 0 {
+		result.Failed = result.Retried[0]
+	}
+	result.Pushes = r.pushes
+	return result, err
+}
+
+type service interface {
+	Push(context.Context) error
+	OnCreated(context.Context, *events.Created) error
+	OnDeleted(context.Context, events.Deleted) error
+}
+
+type handler struct {
+	svc service
+	es  *source.Unmarshaler
+}
+
+// on reports the first failed record and all records after it as batch
+// item failures, so that only unprocessed records are retried.
+func (h handler) on(ctx context.Context, input lambdaevents.SQSEvent) (lambdaevents.SQSEventResponse, error) {
+	ctx = middleware.EnsureRequestID(ctx)
+	response := lambdaevents.SQSEventResponse{
+		BatchItemFailures: []lambdaevents.SQSBatchItemFailure{},
+	}
+	records, err := source.SQS(input)
+	if err != nil {
+		return response, err
+	}
+	failed, err := h.process(ctx, records)
+	for _, id := range failed {
+		response.BatchItemFailures = append(response.BatchItemFailures, lambdaevents.SQSBatchItemFailure{
+			ItemIdentifier: id,
+		})
+	}
+	return response, err
+}
+
+// process handles records in order and returns IDs of the first failed
+// record and of all records after it. Records processed before the
+// failure are pushed, records not pushed are returned as failed, all
+// records when push of the batch fails.
+func (h handler) process(ctx context.Context, records []source.Record) ([]string, error) {
+	// failed is index of the first failed record
+	failed := len(records)
+	// pending is index of the first record processed since the last push
+	pending, processed := -1, 0
+	for i, record := range records {
+		if err := h.handle(ctx, record); err != nil {
+			logging.FromContext(ctx).Error("record failed", "id", record.ID, "error", err)
+			failed = i
+			break
+		}
+
+		if pending < 0 {
+			pending = i
+		}
+		processed++
+		if processed%2 == 0 {
+			if err := h.svc.Push(ctx); err != nil {
+				logging.FromContext(ctx).Error("push failed", "error", err)
+				return source.IDs(records[pending:]), nil
+			}
+			pending = -1
+		}
+	}
+	if pending >= 0 {
+		if err := h.svc.Push(ctx); err != nil {
+			logging.FromContext(ctx).Error("push failed", "error", err)
+			return source.IDs(records[pending:]), nil
+		}
+	}
+	return source.IDs(records[failed:]), nil
+}
+
+// handle dispatches event of record, quarantined record is handled
+func (h handler) handle(ctx context.Context, record source.Record) error {
+	event, err := h.es.Unmarshal(record.Data)
+	switch err {
+	case nil:
+		err = h.call(ctx, record, event)
+	case es.ErrUnknownEventType:
+		err = h.unknown(ctx, record)
+	}
+	return err
+}
+
+func (h handler) call(ctx context.Context, record source.Record, input interface{}) error {
+	var err error
+	switch v := input.(type) {
+	case *events.Created:
+		err = h.svc.OnCreated(ctx, v)
+	case *events.Deleted:
+		err = h.svc.OnDeleted(ctx, *v)
+	default:
+		err = h.unknown(ctx, record)
+	}
+
+	return err
+}
+
+// unknown handles record of event type without handler
+func (h handler) unknown(ctx context.Context, record source.Record) error {
+	logging.FromContext(ctx).Warn("unknown event", "id", record.ID, "type", record.EventType(), "data", string(record.Data))
+	return nil
+}
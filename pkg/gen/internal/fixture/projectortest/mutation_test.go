@@ -0,0 +1,73 @@
+package projectortest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/events"
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/projector"
+	"github.com/mrzahrada/gen/pkg/mutationtest"
+)
+
+func TestRun(t *testing.T) {
+	result, err := Run(context.Background(), &projector.Projector{},
+		&events.Created{ID: "a"}, events.Deleted{ID: "a"}, &events.Created{ID: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.AssertPushed(t)
+	if result.Pushes != 2 {
+		t.Errorf("pushed %d times, want 2", result.Pushes)
+	}
+}
+
+func TestRunFailed(t *testing.T) {
+	result, err := Run(context.Background(), &projector.Projector{Fail: "b"},
+		&events.Created{ID: "a"}, &events.Created{ID: "b"}, events.Deleted{ID: "a"}, events.Deleted{ID: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Failed != 1 {
+		t.Errorf("failed %d, want 1", result.Failed)
+	}
+	// records after the failed one are not processed, so they are retried
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(result.Retried, want) {
+		t.Errorf("retried %v, want %v", result.Retried, want)
+	}
+}
+
+func TestRunPushFailed(t *testing.T) {
+	result, err := Run(context.Background(), &projector.Projector{FailPush: true},
+		&events.Created{ID: "a"}, &events.Created{ID: "b"}, events.Deleted{ID: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// records processed since the last successful push are retried
+	if want := []int{0, 1, 2}; !reflect.DeepEqual(result.Retried, want) {
+		t.Errorf("retried %v, want %v", result.Retried, want)
+	}
+}
+
+func TestRunEventNames(t *testing.T) {
+	// Created is registered as ItemCreated only
+	result, err := Run(context.Background(), &projector.Projector{Fail: "a"}, &events.Created{ID: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Failed != 0 {
+		t.Errorf("failed %d, want 0, event was not passed to OnCreated", result.Failed)
+	}
+}
+
+func TestRunExplicitEventName(t *testing.T) {
+	result, err := Run(context.Background(), &projector.Projector{Fail: "b"},
+		mutationtest.Event{Name: "Removed", Data: events.Deleted{ID: "a"}},
+		mutationtest.Event{Name: "Removed", Data: events.Deleted{ID: "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Failed != 1 {
+		t.Errorf("failed %d, want 1, event was not passed to OnDeleted", result.Failed)
+	}
+}
@@ -0,0 +1,22 @@
+// Package queries is a query service used by tests of gen
+package queries
+
+import (
+	"context"
+
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/commands"
+)
+
+type Service struct{}
+
+func New() (*Service, error) {
+	return &Service{}, nil
+}
+
+func (svc *Service) Get(ctx context.Context, input commands.GetInput) (*commands.Item, error) {
+	return &commands.Item{ID: input.ID}, nil
+}
+
+func (svc *Service) List(ctx context.Context) ([]commands.Item, error) {
+	return nil, nil
+}
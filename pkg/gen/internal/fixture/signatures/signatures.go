@@ -0,0 +1,41 @@
+// Package signatures has services with methods of unsupported signatures
+// used by tests of gen
+package signatures
+
+import "context"
+
+type Input struct {
+	ID string `json:"id"`
+}
+
+type TwoInputs struct{}
+
+func NewTwoInputs() *TwoInputs { return &TwoInputs{} }
+
+func (svc *TwoInputs) Update(ctx context.Context, input Input, other Input) error { return nil }
+
+type ContextLast struct{}
+
+func NewContextLast() *ContextLast { return &ContextLast{} }
+
+func (svc *ContextLast) Update(input Input, ctx context.Context) error { return nil }
+
+type Variadic struct{}
+
+func NewVariadic() *Variadic { return &Variadic{} }
+
+func (svc *Variadic) Update(ctx context.Context, inputs ...Input) error { return nil }
+
+type ErrorFirst struct{}
+
+func NewErrorFirst() *ErrorFirst { return &ErrorFirst{} }
+
+func (svc *ErrorFirst) Get(ctx context.Context, input Input) (error, *Input) { return nil, &input }
+
+type ThreeResults struct{}
+
+func NewThreeResults() *ThreeResults { return &ThreeResults{} }
+
+func (svc *ThreeResults) Get(ctx context.Context, input Input) (*Input, bool, error) {
+	return &input, true, nil
+}
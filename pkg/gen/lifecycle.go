@@ -0,0 +1,28 @@
+package gen
+
+import (
+	"reflect"
+)
+
+// hasClose reports whether t has method Close() error
+func hasClose(t reflect.Type) bool {
+	method, ok := t.MethodByName("Close")
+	if !ok {
+		return false
+	}
+	// first input is the receiver
+	return method.Type.NumIn() == 1 && method.Type.NumOut() == 1 &&
+		method.Type.Out(0) == errorType
+}
+
+// isLifecycleHook reports whether method name of t is Init(context.Context)
+// error or Close() error called by generated main instead of being deployed
+func isLifecycleHook(t reflect.Type, name string) bool {
+	switch name {
+	case "Init":
+		return hasHook(t, name)
+	case "Close":
+		return hasClose(t)
+	}
+	return false
+}
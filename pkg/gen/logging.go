@@ -0,0 +1,116 @@
+package gen
+
+import (
+	"flag"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"os"
+)
+
+// Output configures logger and progress bars of service
+type Output struct {
+	// Quiet logs warnings and errors only, without progress bars
+	Quiet bool
+	// Verbose logs debug messages
+	Verbose bool
+	// JSON logs JSON lines, without progress bars
+	JSON bool
+}
+
+// ServiceOption configures service created by New
+type ServiceOption func(*Service)
+
+// WithOutput configures logger and progress bars of service, logs are
+// written as text at info level by default
+func WithOutput(out Output) ServiceOption {
+	return func(svc *Service) {
+		svc.setOutput(out)
+	}
+}
+
+// ParseOutput parses leading output flags of args, usually os.Args[1:],
+// and returns the rest:
+//
+//	-quiet    logs warnings and errors only, without progress bars
+//	-verbose  logs debug messages
+//	-json     logs JSON lines, without progress bars
+func ParseOutput(args []string) (Output, []string, error) {
+	var out Output
+	flags := flag.NewFlagSet("gen", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	flags.BoolVar(&out.Quiet, "quiet", false, "log warnings and errors only")
+	flags.BoolVar(&out.Verbose, "verbose", false, "log debug messages")
+	flags.BoolVar(&out.JSON, "json", false, "log JSON lines")
+	if err := flags.Parse(args); err != nil {
+		return out, args, err
+	}
+	return out, flags.Args(), nil
+}
+
+// logger returns logger of output writing into w, text lines are written
+// without time
+func (out Output) logger(w io.Writer) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: slog.LevelInfo}
+	if out.Quiet {
+		opts.Level = slog.LevelWarn
+	}
+	if out.Verbose {
+		opts.Level = slog.LevelDebug
+	}
+	if out.JSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{}
+		}
+		return a
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// progress returns writer of progress bars, nil w is the default stdout
+func (out Output) progress(w io.Writer) io.Writer {
+	if out.Quiet || out.JSON {
+		return ioutil.Discard
+	}
+	return w
+}
+
+// setOutput configures logger and progress bars of svc
+func (svc *Service) setOutput(out Output) {
+	svc.out = out
+	w := svc.w
+	if w == nil {
+		w = os.Stderr
+	}
+	svc.logger = out.logger(w)
+	svc.progress = out.progress(svc.w)
+}
+
+// SetOutput redirects logs and progress bars of svc into w, logs are
+// written into stderr and progress bars into stdout by default
+func (svc *Service) SetOutput(w io.Writer) {
+	svc.w = w
+	svc.setOutput(svc.out)
+}
+
+// Logger returns logger set by SetLogger, logger configured by output
+// otherwise
+func (svc *Service) Logger() *slog.Logger {
+	if svc.custom != nil {
+		return svc.custom
+	}
+	if svc.logger == nil {
+		svc.setOutput(svc.out)
+	}
+	return svc.logger
+}
+
+// SetLogger replaces logger of svc, e.g. to log into file. It is kept
+// when output is changed by SetOutput or output flags of Run, nil restores
+// logger configured by output.
+func (svc *Service) SetLogger(l *slog.Logger) {
+	svc.custom = l
+}
@@ -0,0 +1,27 @@
+package gen
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSetLogger(t *testing.T) {
+	var custom, output bytes.Buffer
+	svc := &Service{}
+	svc.SetLogger(slog.New(slog.NewTextHandler(&custom, nil)))
+	svc.SetOutput(&output)
+	svc.setOutput(Output{Verbose: true})
+
+	svc.Logger().Info("generated")
+	if !strings.Contains(custom.String(), "generated") || output.Len() != 0 {
+		t.Errorf("logged %q into logger, %q into output", custom.String(), output.String())
+	}
+
+	svc.SetLogger(nil)
+	svc.Logger().Debug("restored")
+	if !strings.Contains(output.String(), "restored") {
+		t.Errorf("logged %q into output", output.String())
+	}
+}
@@ -3,11 +3,11 @@ package gen
 import (
 	"crypto/md5"
 	"fmt"
-	"log"
 	"path"
 	"reflect"
 	"sort"
-	"strings"
+
+	"github.com/mrzahrada/gen/pkg/source"
 )
 
 type AssetType string
@@ -34,35 +34,31 @@ type Asset interface {
 type Mutation struct {
 	ServiceType reflect.Type
 	Methods     []*Method
-	s3Key       string
-	buildPath   string
-}
-
-func isMutation(method reflect.Method) bool {
-	if !strings.HasPrefix(method.Name, "On") || len(method.Name) <= 2 {
-		return false
-	}
-
-	if method.Type.NumIn() != 3 || method.Type.NumOut() != 1 {
-		return false
-	}
-
-	if !method.Type.Out(0).Implements(reflect.TypeOf((*error)(nil)).Elem()) {
-		return false
-	}
-
-	event := method.Type.In(2)
-
-	if event.Kind() == reflect.Ptr {
-		event = event.Elem()
-	}
-
-	if method.Name[2:] != event.Name() {
-		return false
-	}
-
-	return true
-}
+	Settings    Settings
+	DeadLetter  *DeadLetter
+	Source      Source
+	Push        PushMode
+	// Begin is set when mutation implements Begin(context.Context) error
+	// called before every batch
+	Begin   bool
+	Unknown UnknownPolicy
+	// Checkpoint is set when processed records are tracked per shard
+	Checkpoint *Checkpoint
+	// Factory constructs the mutation in generated main
+	Factory *Factory
+	// Init is set when mutation implements Init(context.Context) error
+	// called once after construction
+	Init bool
+	// Close is set when mutation implements Close() error called on
+	// shutdown
+	Close bool
+	// Instrumented is set when batches and events are traced
+	Instrumented bool
+	s3Key        string
+	buildPath    string
+}
+
+var envelopeType = reflect.TypeOf(source.Envelope{})
 
 func (m *Mutation) Type() AssetType {
 	return MutationType
@@ -84,8 +80,9 @@ func (m *Mutation) S3Key() string {
 	return m.s3Key
 }
 
+// Name returns mutation type name, element type name of pointer mutations
 func (m Mutation) Name() string {
-	return m.ServiceType.Name()
+	return elem(m.ServiceType).Name()
 }
 
 func (m Mutation) EventTypes() []reflect.Type {
@@ -113,35 +110,48 @@ func (m Mutation) Events() []string {
 	return result
 }
 
+// EventNames returns event type names handled by mutation as they appear
+// in event data
 func (m Mutation) EventNames() []string {
-	events := m.EventTypes()
 	result := []string{}
+	for _, method := range m.Methods {
+		result = append(result, method.EventNames...)
+	}
+	return result
+}
 
-	for _, event := range events {
+// Add registers event handler method, names are event type names mapped to
+// the handler, event type name is used when there are none
+func (m *Mutation) Add(method reflect.Method, names ...string) error {
+
+	event := method.Type.In(method.Type.NumIn() - 1)
 
+	if len(names) == 0 {
 		name := event.Name()
 		if event.Kind() == reflect.Ptr {
 			name = event.Elem().Name()
 		}
-
-		result = append(result, name)
+		names = []string{name}
+	}
+	for _, other := range m.Methods {
+		if other.Event == event {
+			return fmt.Errorf("%s and %s handle the same event %s", other.Name(), method.Name, event)
+		}
+		for _, name := range other.EventNames {
+			for _, n := range names {
+				if n == name {
+					return fmt.Errorf("%s and %s handle the same event type name %q", other.Name(), method.Name, name)
+				}
+			}
+		}
 	}
-
-	return result
-}
-
-func (m *Mutation) Add(method reflect.Method) error {
-
-	log.Println("Method:", method)
-	log.Println("ServiceType:", m.ServiceType)
-	log.Println("Event:", method.Type.In(2))
-	log.Println("Event package:", method.Type.In(2).String())
-	log.Println("")
 
 	m.Methods = append(m.Methods, &Method{
 		Method:      method,
 		ServiceType: m.ServiceType,
-		Event:       method.Type.In(2),
+		Event:       event,
+		EventNames:  names,
+		Envelope:    method.Type.NumIn() == 4,
 	})
 	return nil
 }
@@ -151,7 +161,7 @@ func (m Mutation) PackageName() string {
 }
 
 func (m Mutation) Package() string {
-	return m.ServiceType.PkgPath()
+	return elem(m.ServiceType).PkgPath()
 }
 
 func (m Mutation) Key() string {
@@ -171,11 +181,13 @@ func (m Mutation) Imports() []string {
 		}
 	}
 
-	defaultImports := []string{"context", m.ServiceType.PkgPath()}
+	defaultImports := []string{"context", m.Package()}
 	for _, i := range defaultImports {
 		imports[i] = struct{}{}
 	}
 
+	// source package is imported by the template
+	delete(imports, envelopeType.PkgPath())
 	delete(imports, "")
 	result := []string{}
 	for i := range imports {
@@ -205,8 +217,27 @@ type Method struct {
 	ServiceType reflect.Type
 	Method      reflect.Method
 	Event       reflect.Type
-	s3Key       string
-	buildPath   string
+	// EventNames are event type names mapped to event handler
+	EventNames []string
+	// Envelope is set when event handler accepts source.Envelope
+	Envelope bool
+	// Factory constructs the service in generated main
+	Factory *Factory
+	// Init and Close are set when service implements lifecycle hooks
+	// Init(context.Context) error and Close() error
+	Init  bool
+	Close bool
+	// Middlewares wrap method call in generated main
+	Middlewares []Middleware
+	// Validator validates method input in generated main
+	Validator *Validator
+	// Transport serializes method errors in generated main
+	Transport Transport
+	// Instrumented is set when invocations are traced
+	Instrumented bool
+	Settings     Settings
+	s3Key        string
+	buildPath    string
 }
 
 func (m *Method) SetBuildPath(path string) {
@@ -233,11 +264,15 @@ func (m *Method) PackageName() string {
 }
 
 func (m *Method) Package() string {
-	pkg := m.ServiceType.PkgPath()
-	if m.ServiceType.Kind() == reflect.Ptr {
-		pkg = m.ServiceType.Elem().PkgPath()
+	return elem(m.ServiceType).PkgPath()
+}
+
+// elem returns element type of pointer t, t otherwise
+func elem(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
 	}
-	return pkg
+	return t
 }
 
 func (m *Method) Inputs() []reflect.Type {
@@ -261,41 +296,43 @@ func (m *Method) Outputs() []reflect.Type {
 	return result
 }
 
+// checkSignature returns error of method which is not shaped
+// (ctx?, input?) (T?, error?), context is the first input
+func (m *Method) checkSignature() error {
+	name := m.ServiceType.String() + "." + m.Name()
+	if m.Method.Type.IsVariadic() {
+		return fmt.Errorf("method %s is variadic", name)
+	}
+	inputs := m.Inputs()
+	if len(inputs) > 0 && isContext(inputs[0]) {
+		inputs = inputs[1:]
+	}
+	if len(inputs) > 1 || len(inputs) == 1 && isContext(inputs[0]) {
+		return fmt.Errorf("method %s does not take (context.Context, input), (context.Context) or (input)", name)
+	}
+	outputs := m.Outputs()
+	if len(outputs) > 2 || len(outputs) == 2 && (outputs[0] == errorType || outputs[1] != errorType) {
+		return fmt.Errorf("method %s does not return (T, error), (T), (error) or nothing", name)
+	}
+	return nil
+}
+
+// Imports returns packages of all types referenced by method signature
 func (m *Method) Imports() []string {
 
 	types := []reflect.Type{m.ServiceType}
 	types = append(types, m.Inputs()...)
 	types = append(types, m.Outputs()...)
 
-	imports := map[string]struct{}{}
+	im := newImporter(nil, nil)
 	for _, t := range types {
-		if t.Kind() == reflect.Ptr {
-			t = t.Elem()
-		}
-		imports[t.PkgPath()] = struct{}{}
+		im.typeName(t)
 	}
-	delete(imports, "")
-	result := []string{}
-	for i := range imports {
-		result = append(result, i)
-	}
-	sort.Strings(result)
-	return result
+	return im.paths()
 }
 
 func (m *Method) String() string {
-	inputs := []string{}
-	for _, in := range m.Inputs() {
-		inputs = append(inputs, in.String())
-	}
-	outputs := []string{}
-	for _, out := range m.Outputs() {
-		outputs = append(outputs, out.String())
-	}
-	return fmt.Sprintf("func(%s) (%s)",
-		strings.Join(inputs, ","),
-		strings.Join(outputs, ","),
-	)
+	return newImporter(nil, nil).signature(m)
 }
 
 func (m *Method) Key() string {
@@ -0,0 +1,31 @@
+package gen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/commands"
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/signatures"
+)
+
+func TestMethodSignatures(t *testing.T) {
+	svc := newTestService(t)
+	if err := svc.AddCommands(&commands.Service{}); err != nil {
+		t.Fatal(err)
+	}
+	for service, factory := range map[string]interface{}{
+		"*signatures.TwoInputs.Update":   signatures.NewTwoInputs,
+		"*signatures.ContextLast.Update": signatures.NewContextLast,
+		"*signatures.Variadic.Update":    signatures.NewVariadic,
+		"*signatures.ErrorFirst.Get":     signatures.NewErrorFirst,
+		"*signatures.ThreeResults.Get":   signatures.NewThreeResults,
+	} {
+		svc := newTestService(t)
+		v := reflect.ValueOf(factory).Call(nil)[0].Interface()
+		err := svc.AddCommands(v, WithFactory(factory))
+		if err == nil || !strings.Contains(err.Error(), service) {
+			t.Errorf("%s: error %v, want error naming the method", service, err)
+		}
+	}
+}
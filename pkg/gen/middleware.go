@@ -0,0 +1,78 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/mrzahrada/gen/pkg/middleware"
+)
+
+// Middleware wraps service method call in generated main
+type Middleware struct {
+	Package string
+	Name    string
+}
+
+// WithMiddleware wraps commands and queries by middlewares, the first
+// middleware is the outermost. Middlewares have to be exported package
+// level functions, e.g. middleware.Recover, middleware.RequestID and
+// middleware.Timing.
+func WithMiddleware(middlewares ...middleware.Middleware) Option {
+	return func(o *options) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// middlewareRefs returns validated middlewares referenced by generated main
+func (o *options) middlewareRefs() ([]Middleware, error) {
+	result := []Middleware{}
+	for _, fn := range o.middlewares {
+		v := reflect.ValueOf(fn)
+		if v.IsNil() {
+			return nil, errors.New("middleware is nil")
+		}
+		pkg, name, ok := funcName(v)
+		if !ok {
+			return nil, fmt.Errorf("middleware %s.%s is not an exported package level function", pkg, name)
+		}
+		result = append(result, Middleware{Package: pkg, Name: name})
+	}
+	return result, nil
+}
+
+// Payload returns type of request payload, nil when method has none
+func (m *Method) Payload() reflect.Type {
+	for _, t := range m.Inputs() {
+		if !isContext(t) {
+			return t
+		}
+	}
+	return nil
+}
+
+// Call returns statements calling method inside middleware.Handler with
+// context ctx and decoded payload input
+func (m *Method) Call() string {
+	args := []string{}
+	for _, t := range m.Inputs() {
+		if isContext(t) {
+			args = append(args, "ctx")
+		} else {
+			args = append(args, "input")
+		}
+	}
+	call := fmt.Sprintf("svc.%s(%s)", m.Name(), strings.Join(args, ", "))
+
+	outputs := m.Outputs()
+	switch {
+	case len(outputs) == 0:
+		return call + "\n\t\treturn nil, nil"
+	case len(outputs) == 1 && outputs[0] == errorType:
+		return "return nil, " + call
+	case len(outputs) == 1:
+		return "return " + call + ", nil"
+	}
+	return "return " + call
+}
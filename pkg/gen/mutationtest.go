@@ -0,0 +1,127 @@
+package gen
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"text/template"
+)
+
+var mutationTestTmpl = `// DO NOT EDIT! Generated code.
+
+// Package {{ .TestPackage }} runs events through the generated handler of
+// {{ typeName .ServiceType }}.
+package {{ .TestPackage }}
+
+import ({{ template "imports" . }}
+	"github.com/mrzahrada/gen/pkg/mutationtest"
+)
+
+// recorder counts Push calls of the mutation
+type recorder struct {
+	service
+	pushes int
+}
+{{ if .Push.Required }}
+func (r *recorder) Push(ctx context.Context) error {
+	r.pushes++
+	return r.service.Push(ctx)
+}
+{{ end }}
+// eventName returns event type name registered for event, the first one
+// when the handler is mapped to several names
+func eventName(event interface{}) string {
+	switch event.(type) { {{- range $method := .Methods }}
+	case {{ elemName $method.Event }}, *{{ elemName $method.Event }}:
+		return {{ printf "%q" (index $method.EventNames 0) }}{{ end }}
+	}
+	return ""
+}
+
+// Run passes input events to svc through the generated handler as
+// {{ .Source.Event }}.{{ if .Source.BatchItemFailures }} Events are passed in a single batch.{{ else }} Events are passed one by one.{{ end }}
+// Events are marshaled with event type names registered by the mutation,
+// mutationtest.Event sets the name explicitly.
+func Run(ctx context.Context, svc {{ typeName .ServiceType }}, input ...interface{}) (mutationtest.Result, error) {
+	result := mutationtest.Result{Failed: -1}
+	records, err := mutationtest.MarshalNamed(eventName, input...)
+	if err != nil {
+		return result, err
+	}
+
+	r := &recorder{service: svc}
+	h := handler{
+		svc: r,
+		es:  {{ template "unmarshaler" . }},{{ if .DeadLetter }}
+		tracker: {{ template "tracker" . }},{{ end }}
+	}{{ if .DeadLetter }}{{ if .DeadLetter.Custom }}
+	h.sink = svc{{ else }}
+	sink := deadletter.NewMemory()
+	h.sink = sink{{ end }}{{ end }}{{ if .Checkpoint }}
+	h.checkpoints = checkpoint.NewMemory(){{ end }}
+{{ if .Source.BatchItemFailures }}{{ if eq .Source.Name "DynamoDB" }}
+	batch, err := mutationtest.DynamoDB(records...)
+	if err != nil {
+		return result, err
+	}
+	response, err := h.on(ctx, batch){{ else }}
+	response, err := h.on(ctx, mutationtest.{{ .Source.Name }}(records...)){{ end }}
+	for _, failure := range response.BatchItemFailures {
+		for i, record := range records {
+			if record.ID == failure.ItemIdentifier {
+				result.Retried = append(result.Retried, i)
+			}
+		}
+	}
+	if len(result.Retried) > 0 {
+		result.Failed = result.Retried[0]
+	}{{ else }}
+	for i, record := range records {
+		if err = h.on(ctx, mutationtest.{{ .Source.Name }}(record)); err != nil {
+			result.Failed = i
+			break
+		}
+	}{{ end }}
+	result.Pushes = r.pushes{{ if .DeadLetter }}{{ if not .DeadLetter.Custom }}
+	result.Quarantined = sink.Letters(){{ end }}{{ end }}
+	return result, err
+}
+{{ template "dispatch" . }}`
+
+// mutationTest is data of mutationTestTmpl
+type mutationTest struct {
+	*Mutation
+	TestPackage string
+}
+
+// MutationTest returns source of package named pkg, which runs events
+// through the same dispatch code as the deployed mutation
+func (svc *Service) MutationTest(pkg string) (string, error) {
+	if svc.Mutation == nil {
+		return "", errors.New("no mutation registered")
+	}
+	tmpl, err := template.New("MutationTest").Funcs(newImporter(nil, nil).funcs()).Parse(mutationTestTmpl + mutationDispatchTmpl)
+	if err != nil {
+		return "", err
+	}
+	return execute(tmpl, mutationTest{svc.Mutation, pkg}, fixedImports[MutationType])
+}
+
+// WriteMutationTest writes mutation test package into directory dir, the
+// package is named after the directory
+func (svc *Service) WriteMutationTest(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	content, err := svc.MutationTest(path.Base(dir))
+	if err != nil {
+		return err
+	}
+	p := path.Join(dir, "mutation.go")
+	if err := writeFile(p, content); err != nil {
+		return err
+	}
+	svc.Logger().Info("generated mutation test", "path", p)
+	return nil
+}
@@ -0,0 +1,237 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mrzahrada/gen/pkg/middleware"
+)
+
+// Option configures methods registered by AddCommands, AddQueries and
+// AddMutation
+type Option func(*options)
+
+type options struct {
+	all     []MethodOption
+	methods map[string][]MethodOption
+
+	// service construction
+	factoryFunc interface{}
+	factoryName string
+	providers   []interface{}
+
+	instrumentation TelemetryExporter
+
+	// commands and queries only
+	middlewares []middleware.Middleware
+	validator   func(interface{}) error
+	transport   Transport
+
+	// mutation only
+	deadLetter    *DeadLetter
+	source        Source
+	push          *PushMode
+	unknownEvents UnknownPolicy
+	checkpoint    *Checkpoint
+	// event type names by event handler
+	eventNames     map[string][]string
+	strictHandlers bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		methods: map[string][]MethodOption{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// settings returns validated settings of method name
+func (o *options) settings(name string) (Settings, error) {
+	settings := Settings{}
+	opts := append(append([]MethodOption{}, o.all...), o.methods[name]...)
+	for _, opt := range opts {
+		if err := opt(&settings); err != nil {
+			return settings, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return settings, nil
+}
+
+// mutationOnly checks that no mutation option is given for other methods
+func (o *options) mutationOnly() error {
+	if o.deadLetter != nil {
+		return errors.New("dead letter applies to mutations only")
+	}
+	if o.source != "" {
+		return errors.New("source applies to mutations only")
+	}
+	if o.push != nil {
+		return errors.New("push applies to mutations only")
+	}
+	if o.unknownEvents != "" {
+		return errors.New("unknown events policy applies to mutations only")
+	}
+	if o.checkpoint != nil {
+		return errors.New("checkpoint applies to mutations only")
+	}
+	if o.eventNames != nil || o.strictHandlers {
+		return errors.New("event handlers apply to mutations only")
+	}
+	return nil
+}
+
+// unknown checks that options were not given for methods out of names
+func (o *options) unknown(names []string) error {
+	known := map[string]struct{}{}
+	for _, name := range names {
+		known[name] = struct{}{}
+	}
+	missing := []string{}
+	for name := range o.methods {
+		if _, ok := known[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("options for unknown methods: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// WithOptions applies opts to every registered method
+func WithOptions(opts ...MethodOption) Option {
+	return func(o *options) {
+		o.all = append(o.all, opts...)
+	}
+}
+
+// WithMethodOptions applies opts to method name
+func WithMethodOptions(name string, opts ...MethodOption) Option {
+	return func(o *options) {
+		o.methods[name] = append(o.methods[name], opts...)
+	}
+}
+
+// MethodOption configures deployment of a method
+type MethodOption func(*Settings) error
+
+// Memory sets memory size in MB
+func Memory(size int) MethodOption {
+	return func(s *Settings) error {
+		if size < 128 || size > 10240 {
+			return fmt.Errorf("memory size %d out of range 128-10240 MB", size)
+		}
+		s.MemorySize = size
+		return nil
+	}
+}
+
+// Timeout sets function timeout
+func Timeout(d time.Duration) MethodOption {
+	return func(s *Settings) error {
+		if d < time.Second || d > 15*time.Minute {
+			return fmt.Errorf("timeout %s out of range 1s-15m", d)
+		}
+		if d%time.Second != 0 {
+			return fmt.Errorf("timeout %s has to be in whole seconds", d)
+		}
+		s.Timeout = int(d / time.Second)
+		return nil
+	}
+}
+
+// ReservedConcurrency sets reserved concurrent executions
+func ReservedConcurrency(n int) MethodOption {
+	return func(s *Settings) error {
+		if n < 0 {
+			return fmt.Errorf("reserved concurrency %d is negative", n)
+		}
+		s.ReservedConcurrency = &n
+		return nil
+	}
+}
+
+// reservedEnv are environment variables set by Lambda runtime
+var reservedEnv = map[string]struct{}{
+	"_HANDLER":                        {},
+	"_X_AMZN_TRACE_ID":                {},
+	"AWS_DEFAULT_REGION":              {},
+	"AWS_REGION":                      {},
+	"AWS_EXECUTION_ENV":               {},
+	"AWS_LAMBDA_FUNCTION_NAME":        {},
+	"AWS_LAMBDA_FUNCTION_MEMORY_SIZE": {},
+	"AWS_LAMBDA_FUNCTION_VERSION":     {},
+	"AWS_LAMBDA_INITIALIZATION_TYPE":  {},
+	"AWS_LAMBDA_LOG_GROUP_NAME":       {},
+	"AWS_LAMBDA_LOG_STREAM_NAME":      {},
+	"AWS_ACCESS_KEY":                  {},
+	"AWS_ACCESS_KEY_ID":               {},
+	"AWS_SECRET_ACCESS_KEY":           {},
+	"AWS_SESSION_TOKEN":               {},
+	"AWS_LAMBDA_RUNTIME_API":          {},
+	"LAMBDA_TASK_ROOT":                {},
+	"LAMBDA_RUNTIME_DIR":              {},
+}
+
+// Env sets environment variable, variables reserved by Lambda runtime are
+// rejected
+func Env(key, value string) MethodOption {
+	return func(s *Settings) error {
+		if key == "" {
+			return errors.New("empty environment variable name")
+		}
+		if _, ok := reservedEnv[key]; ok {
+			return fmt.Errorf("environment variable %s is reserved", key)
+		}
+		if s.Environment == nil {
+			s.Environment = map[string]string{}
+		}
+		s.Environment[key] = value
+		return nil
+	}
+}
+
+// VPC places function into subnets with security groups
+func VPC(subnetIDs []string, securityGroupIDs []string) MethodOption {
+	return func(s *Settings) error {
+		if len(subnetIDs) == 0 || len(securityGroupIDs) == 0 {
+			return errors.New("vpc requires at least one subnet and security group")
+		}
+		s.VPC = &ConfigVPC{
+			SubnetIDs:        subnetIDs,
+			SecurityGroupIDs: securityGroupIDs,
+		}
+		return nil
+	}
+}
+
+// Allow adds IAM policy statement allowing actions on resources
+func Allow(actions []string, resources ...string) MethodOption {
+	return policy("Allow", actions, resources)
+}
+
+// Deny adds IAM policy statement denying actions on resources
+func Deny(actions []string, resources ...string) MethodOption {
+	return policy("Deny", actions, resources)
+}
+
+func policy(effect string, actions []string, resources []string) MethodOption {
+	return func(s *Settings) error {
+		if len(actions) == 0 || len(resources) == 0 {
+			return errors.New("policy requires at least one action and resource")
+		}
+		s.Policies = append(s.Policies, ConfigPolicy{
+			Effect:    effect,
+			Actions:   actions,
+			Resources: resources,
+		})
+		return nil
+	}
+}
@@ -0,0 +1,44 @@
+package gen
+
+import (
+	"testing"
+
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/projector"
+)
+
+func TestEnv(t *testing.T) {
+	for key, reserved := range map[string]bool{
+		"AWS_REGION":             true,
+		"AWS_LAMBDA_RUNTIME_API": true,
+		"LAMBDA_TASK_ROOT":       true,
+		"_HANDLER":               true,
+		"AWS_PROFILE_BUCKET":     false,
+		"LAMBDA_TABLE":           false,
+		"TABLE":                  false,
+	} {
+		err := Env(key, "value")(&Settings{})
+		if reserved && err == nil {
+			t.Errorf("reserved %s accepted", key)
+		}
+		if !reserved && err != nil {
+			t.Errorf("%s rejected: %v", key, err)
+		}
+	}
+}
+
+func TestMutationOptions(t *testing.T) {
+	svc := newTestService(t)
+	err := svc.AddMutation(&projector.Projector{}, WithMethodOptions("Projector", Memory(512)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Mutation.Name() != "Projector" {
+		t.Errorf("name %q, want Projector", svc.Mutation.Name())
+	}
+	if svc.Mutation.Package() != "github.com/mrzahrada/gen/pkg/gen/internal/fixture/projector" {
+		t.Errorf("package %q", svc.Mutation.Package())
+	}
+	if svc.Mutation.Settings.MemorySize != 512 {
+		t.Errorf("memory size %d, want 512", svc.Mutation.Settings.MemorySize)
+	}
+}
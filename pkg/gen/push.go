@@ -0,0 +1,100 @@
+package gen
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// PushMode configures when Push of mutation is called
+type PushMode struct {
+	mode  string
+	every int
+}
+
+var (
+	// PushPerBatch calls Push once after every batch
+	PushPerBatch = PushMode{mode: "batch"}
+	// PushPerRecord calls Push after every processed record
+	PushPerRecord = PushMode{mode: "every", every: 1}
+	// PushNone never calls Push, mutation does not need to implement it
+	PushNone = PushMode{mode: "none"}
+)
+
+// PushEvery calls Push after every n processed records and after the last
+// record of a batch
+func PushEvery(n int) PushMode {
+	return PushMode{mode: "every", every: n}
+}
+
+// WithPush sets push mode of mutation. By default mutation implementing
+// Push is pushed per batch.
+func WithPush(mode PushMode) Option {
+	return func(o *options) {
+		o.push = &mode
+	}
+}
+
+// Batch reports whether Push is called once per batch
+func (p PushMode) Batch() bool {
+	return p.mode == "batch"
+}
+
+// Every returns number of records pushed together, zero when Push is not
+// called per records
+func (p PushMode) Every() int {
+	if p.mode != "every" {
+		return 0
+	}
+	return p.every
+}
+
+// Required reports whether mutation has to implement Push
+func (p PushMode) Required() bool {
+	return p.mode != "none"
+}
+
+func (p PushMode) validate() error {
+	switch p.mode {
+	case "batch", "none":
+		return nil
+	case "every":
+		if p.every < 1 {
+			return fmt.Errorf("push every %d records", p.every)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown push mode %q", p.mode)
+}
+
+// pushMode returns validated push mode of mutation t
+func pushMode(t reflect.Type, mode *PushMode) (PushMode, error) {
+	if mode == nil {
+		if hasHook(t, "Push") {
+			return PushPerBatch, nil
+		}
+		if _, ok := t.MethodByName("Push"); ok {
+			return PushNone, fmt.Errorf("%s.Push is not Push(context.Context) error", t)
+		}
+		return PushNone, nil
+	}
+	if err := mode.validate(); err != nil {
+		return *mode, err
+	}
+	if mode.Required() && !hasHook(t, "Push") {
+		return *mode, fmt.Errorf("%s does not implement Push(context.Context) error", t)
+	}
+	return *mode, nil
+}
+
+// hasHook reports whether t has method name(context.Context) error
+func hasHook(t reflect.Type, name string) bool {
+	method, ok := t.MethodByName(name)
+	if !ok {
+		return false
+	}
+	// first input is the receiver
+	return method.Type.NumIn() == 2 && method.Type.NumOut() == 1 &&
+		method.Type.In(1) == reflect.TypeOf((*context.Context)(nil)).Elem() &&
+		method.Type.Out(0) == errorType
+}
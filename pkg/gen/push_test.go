@@ -0,0 +1,44 @@
+package gen
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type pushing struct{}
+
+func (pushing) Push(ctx context.Context) error { return nil }
+
+type pushingWithoutContext struct{}
+
+func (pushingWithoutContext) Push() error { return nil }
+
+func TestPushMode(t *testing.T) {
+	every := PushEvery(10)
+	for _, test := range []struct {
+		value interface{}
+		mode  *PushMode
+		want  PushMode
+		err   bool
+	}{
+		{value: struct{}{}, want: PushNone},
+		{value: pushing{}, want: PushPerBatch},
+		{value: pushing{}, mode: &every, want: every},
+		{value: pushingWithoutContext{}, err: true},
+		{value: pushingWithoutContext{}, mode: &every, err: true},
+		{value: struct{}{}, mode: &every, err: true},
+	} {
+		typ := reflect.TypeOf(test.value)
+		got, err := pushMode(typ, test.mode)
+		if test.err {
+			if err == nil {
+				t.Errorf("push mode of %s accepted", typ)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("push mode of %s is %v, %v, want %v", typ, got, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/vbauerster/mpb"
+	"github.com/vbauerster/mpb/decor"
+)
+
+// replayTmpl is a command feeding archived events into the mutation
+// through the same dispatch code as the deployed mutation
+var replayTmpl = `// DO NOT EDIT! Generated code.
+
+// Command replay feeds archived events into {{ typeName .ServiceType }}
+// through the generated handler. Offset of the next record is written
+// into stdout as JSON line {"offset":N} after every batch.
+package main
+
+import ({{ template "imports" . }}
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strconv"
+	"github.com/mrzahrada/gen/pkg/replay"
+)
+
+func main() {
+	from := flag.String("from", "", "events archive: s3://bucket/prefix, kinesis://stream or JSON lines file")
+	offset := flag.Int("offset", 0, "number of records to skip")
+	size := flag.Int("batch", 100, "number of records processed together")
+	flag.Parse()
+
+	{{ template "handler" . }}{{ if .Checkpoint }}
+	// replay is resumed by offset, checkpoints of the source stay intact
+	h.checkpoints = checkpoint.NewMemory(){{ end }}{{ template "init" . }}
+
+	ctx, stop := {{ pkg "os/signal" }}.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := h.replay(ctx, *from, *offset, *size); err != nil {
+		{{ pkg "github.com/mrzahrada/gen/pkg/logging" }}.Default().Error("replay stopped", "error", err){{ if .Close }}
+		svc.Close(){{ end }}
+		os.Exit(1)
+	}{{ if .Close }}
+	if err := svc.Close(); err != nil {
+		{{ pkg "github.com/mrzahrada/gen/pkg/logging" }}.Default().Error("close failed", "error", err)
+		os.Exit(1)
+	}{{ end }}
+}
+
+// replay processes records of archive from after offset in batches of
+// size. Records are identified by their position counted from 1.{{ if .DeadLetter }} Batch
+// is retried from the failed record until the record is quarantined.{{ end }}
+func (h handler) replay(ctx context.Context, from string, offset, size int) error {
+	reader, err := replay.Open(from)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	for i := 0; i < offset; i++ {
+		if _, err := reader.Read(ctx); err != nil {
+			if err == io.EOF {
+				err = errors.New("offset " + strconv.Itoa(offset) + " is past the last record " + strconv.Itoa(i))
+			}
+			return err
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	records := []source.Record{}
+	eof := false{{ if .DeadLetter }}
+	// last is ID of the last failed record failed attempts times
+	last, attempts := "", 0{{ end }}
+	for {
+		for !eof && len(records) < size {
+			data, err := reader.Read(ctx)
+			if err == io.EOF {
+				eof = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			records = append(records, source.Record{
+				ID:     strconv.Itoa(offset + len(records) + 1),
+				Stream: from,
+				Shard:  "replay",
+				Data:   data,
+			})
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		failed, err := h.process(ctx, records)
+		if err != nil {
+			// records are not pushed, replay resumes at offset
+			return err
+		}
+		done := len(records) - len(failed)
+		offset += done
+		records = records[done:]
+		if err := encoder.Encode(map[string]int{"offset": offset}); err != nil {
+			return err
+		}
+		if len(failed) == 0 {
+			continue
+		}{{ if .DeadLetter }}
+		if failed[0] != last {
+			last, attempts = failed[0], 0
+		}
+		attempts++
+		if attempts <= {{ .DeadLetter.MaxAttempts }} {
+			continue
+		}{{ end }}
+		return errors.New("record " + failed[0] + " failed")
+	}
+}
+{{ template "dispatch" . }}`
+
+// replayImports are imported by replayTmpl in addition to mutation
+// imports, by path
+var replayImports = map[string]string{
+	"encoding/json":                       "json",
+	"errors":                              "errors",
+	"flag":                                "flag",
+	"io":                                  "io",
+	"os":                                  "os",
+	"strconv":                             "strconv",
+	"github.com/mrzahrada/gen/pkg/replay": "replay",
+}
+
+// replayProgram returns source of replay command of mutation m
+func replayProgram(m *Mutation) (string, error) {
+	tmpl, err := template.New("Replay").Funcs(newImporter(nil, nil).funcs()).Parse(replayTmpl + mutationDispatchTmpl)
+	if err != nil {
+		return "", err
+	}
+	fixed := map[string]string{}
+	for p, name := range fixedImports[MutationType] {
+		fixed[p] = name
+	}
+	for p, name := range replayImports {
+		fixed[p] = name
+	}
+	return execute(tmpl, m, fixed)
+}
+
+// Replay feeds events of archive from into the registered mutation, e.g.
+// to backfill a new read model. The mutation is constructed by its factory
+// and records pass the same dispatch code as in the deployed function,
+// run locally with environment of the function. First offset records are
+// skipped, the rest is processed in batches of size records. Returns
+// offset of the first record which was not pushed, replay can be resumed
+// from it.
+func (svc *Service) Replay(ctx context.Context, from string, offset, size int) (int, error) {
+	if svc.Mutation == nil {
+		return offset, errors.New("no mutation registered")
+	}
+	if size < 1 {
+		return offset, fmt.Errorf("batch size %d is not positive", size)
+	}
+	bin, err := svc.buildReplay(ctx)
+	if err != nil {
+		return offset, err
+	}
+
+	cmd := exec.CommandContext(ctx, bin,
+		"-from", from,
+		"-offset", strconv.Itoa(offset),
+		"-batch", strconv.Itoa(size),
+	)
+	cmd.Env = os.Environ()
+	for key, value := range svc.Mutation.Settings.Environment {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	cmd.Stderr = svc.w
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return offset, err
+	}
+	svc.Logger().Debug("exec", "cmd", bin, "args", strings.Join(cmd.Args[1:], " "))
+	if err := cmd.Start(); err != nil {
+		return offset, err
+	}
+
+	start := offset
+	p := mpb.New(
+		mpb.WithWidth(60),
+		mpb.WithOutput(svc.progress),
+	)
+	bar := p.AddSpinner(0, mpb.SpinnerOnLeft,
+		mpb.PrependDecorators(
+			decor.Name("replaying "+svc.Mutation.Name()),
+		),
+		mpb.AppendDecorators(
+			newCountDecorator(offset),
+			decor.Elapsed(decor.ET_STYLE_GO, decor.WC{W: 8}),
+		),
+	)
+
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		progress := struct {
+			Offset int `json:"offset"`
+		}{}
+		if err := json.Unmarshal(scanner.Bytes(), &progress); err != nil {
+			// mutation writing into stdout
+			svc.Logger().Debug("replay output", "line", scanner.Text())
+			continue
+		}
+		bar.IncrBy(progress.Offset - offset)
+		offset = progress.Offset
+	}
+	if err := cmd.Wait(); err != nil {
+		p.Abort(bar, false)
+		p.Wait()
+		if ctx.Err() != nil {
+			return offset, ctx.Err()
+		}
+		return offset, fmt.Errorf("replay: %v", err)
+	}
+	bar.SetTotal(int64(offset-start), true)
+	p.Wait()
+	return offset, nil
+}
+
+// buildReplay compiles replay command of the mutation for the host and
+// returns path of the binary
+func (svc *Service) buildReplay(ctx context.Context) (string, error) {
+	content, err := replayProgram(svc.Mutation)
+	if err != nil {
+		return "", err
+	}
+	dir := path.Join(svc.dir, "replay", svc.Mutation.Key())
+	if err := writeFile(path.Join(dir, "main.go"), content); err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", "replay")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		svc.Logger().Debug("build failed", "asset", svc.Mutation.Name(), "source", content)
+		return "", fmt.Errorf("%s: %v: %s", cmd, err, strings.TrimSpace(stderr.String()))
+	}
+	return path.Join(dir, "replay"), nil
+}
+
+// countDecorator shows number of replayed records including skipped ones
+type countDecorator struct {
+	decor.WC
+	skipped int
+}
+
+func newCountDecorator(skipped int) decor.Decorator {
+	d := &countDecorator{skipped: skipped}
+	d.Init()
+	return d
+}
+
+func (d *countDecorator) Decor(st *decor.Statistics) string {
+	return d.FormatMsg(fmt.Sprintf("%d records", int64(d.skipped)+st.Current))
+}
@@ -0,0 +1,80 @@
+package gen
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/projector"
+)
+
+// newReplayService returns service replaying events into projector, the
+// replay command is built inside the module
+func newReplayService(t *testing.T, opts ...Option) *Service {
+	t.Helper()
+	dir, err := ioutil.TempDir("testdata", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	svc := newTestService(t)
+	svc.dir = dir
+	svc.w = &bytes.Buffer{}
+	svc.progress = ioutil.Discard
+	if err := svc.AddMutation(&projector.Projector{}, opts...); err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func writeEvents(t *testing.T) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "events.jsonl")
+	events := `{"t":"Created","d":{"id":"a"}}
+{"t":"Deleted","d":{"id":"a"}}
+{"t":"Created","d":{"id":"b"}}
+{"t":"Created","d":{"id":"c"}}
+`
+	if err := ioutil.WriteFile(p, []byte(events), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestReplay(t *testing.T) {
+	svc := newReplayService(t)
+	next, err := svc.Replay(context.Background(), writeEvents(t), 1, 2)
+	if err != nil {
+		t.Fatalf("%v: %s", err, svc.w)
+	}
+	if next != 4 {
+		t.Errorf("next offset %d, want 4", next)
+	}
+}
+
+func TestReplayFailed(t *testing.T) {
+	svc := newReplayService(t, WithOptions(Env(projector.EnvFail, "b")))
+	next, err := svc.Replay(context.Background(), writeEvents(t), 0, 10)
+	if err == nil {
+		t.Fatal("replay of failing event succeeded")
+	}
+	// events before the failed third one are pushed
+	if next != 2 {
+		t.Errorf("next offset %d, want 2: %s", next, svc.w)
+	}
+}
+
+func TestReplayPushFailed(t *testing.T) {
+	svc := newReplayService(t, WithOptions(Env(projector.EnvFailPush, "1")))
+	next, err := svc.Replay(context.Background(), writeEvents(t), 1, 2)
+	if err == nil {
+		t.Fatal("replay with failing push succeeded")
+	}
+	// handled records are not pushed, so replay resumes at the same offset
+	if next != 1 {
+		t.Errorf("next offset %d, want 1: %s", next, svc.w)
+	}
+}
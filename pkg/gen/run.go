@@ -0,0 +1,95 @@
+package gen
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// Run executes command of args, usually os.Args[1:]:
+//
+//	build                        builds assets
+//	publish                      builds and uploads assets
+//	replay [options] <mutation>  replays archived events into mutation
+//
+// Assets are built and published when no command is given. The command
+// may be preceded by -quiet, -verbose or -json output flags. Interrupt
+// signal cancels the command.
+func (svc *Service) Run(args []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return svc.RunContext(ctx, args)
+}
+
+// RunContext executes command of args like Run, canceling ctx cancels the
+// command
+func (svc *Service) RunContext(ctx context.Context, args []string) error {
+	out, args, err := ParseOutput(args)
+	if err != nil {
+		return err
+	}
+	if out != (Output{}) {
+		svc.setOutput(out)
+	}
+
+	if len(args) == 0 {
+		if err := svc.BuildContext(ctx); err != nil {
+			return err
+		}
+		return svc.PublishContext(ctx)
+	}
+	switch args[0] {
+	case "build":
+		return svc.BuildContext(ctx)
+	case "publish":
+		if err := svc.BuildContext(ctx); err != nil {
+			return err
+		}
+		return svc.PublishContext(ctx)
+	case "replay":
+		return svc.runReplay(ctx, args[1:])
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func (svc *Service) runReplay(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	from := flags.String("from", "", "events archive: s3://bucket/prefix, kinesis://stream or JSON lines file")
+	offset := flags.Int("offset", 0, "number of records to skip, used to resume replay")
+	size := flags.Int("batch", 100, "number of records pushed together")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: replay [options] <mutation>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *from == "" {
+		flags.Usage()
+		return errors.New("replay requires mutation and -from")
+	}
+	if svc.Mutation == nil || svc.Mutation.Name() != flags.Arg(0) {
+		return fmt.Errorf("unknown mutation %q", flags.Arg(0))
+	}
+
+	next, err := svc.Replay(ctx, *from, *offset, *size)
+	if err != nil {
+		svc.Logger().Error("replay stopped, resume with -offset", "offset", next, "error", err)
+		return err
+	}
+	svc.Logger().Info("replayed records", "records", next)
+	return nil
+}
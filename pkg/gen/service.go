@@ -1,15 +1,16 @@
 package gen
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
 
-	"github.com/fatih/color"
 	"github.com/vbauerster/mpb"
 	"github.com/vbauerster/mpb/decor"
 )
@@ -26,85 +27,208 @@ type Service struct {
 	dir string
 
 	cfg *CDKConfig
+
+	out      Output
+	w        io.Writer
+	logger   *slog.Logger
+	custom   *slog.Logger
+	progress io.Writer
 }
 
-// New Service
-func New() (*Service, error) {
+// New Service. Logs are written as text at info level unless configured
+// by WithOutput.
+func New(opts ...ServiceOption) (*Service, error) {
+	svc := &Service{
+		Commands: []*Command{},
+		Queries:  []*Query{},
+	}
+	svc.setOutput(Output{})
+	for _, opt := range opts {
+		opt(svc)
+	}
+
 	cfg, dir, err := findConfig()
 	if err != nil {
 		return nil, err
 	}
-	fmt.Println(cfg.Context.Bucket)
+	svc.Logger().Debug("found config", "dir", dir, "bucket", cfg.Context.Bucket)
 	dirAbs, err := filepath.Abs(path.Join(dir, "cdk.out"))
 	if err != nil {
 		return nil, err
 	}
-
-	return &Service{
-		Commands: []*Command{},
-		Queries:  []*Query{},
-		dir:      dirAbs,
-		cfg:      cfg,
-	}, nil
+	svc.dir = dirAbs
+	svc.cfg = cfg
+	return svc, nil
 }
 
 // AddCommands -
-func (svc *Service) AddCommands(input interface{}) {
-	v := reflect.TypeOf(input)
-	for i := 0; i < v.NumMethod(); i++ {
-		method := &Method{
-			Method:      v.Method(i),
-			ServiceType: v,
-		}
-
+func (svc *Service) AddCommands(input interface{}, opts ...Option) error {
+	methods, err := methods(input, opts, svc.Logger())
+	if err != nil {
+		return err
+	}
+	for _, method := range methods {
 		svc.Commands = append(svc.Commands, &Command{method})
 	}
+	return nil
 }
 
 // AddQueries -
-func (svc *Service) AddQueries(input interface{}) {
-	v := reflect.TypeOf(input)
-	for i := 0; i < v.NumMethod(); i++ {
-		method := &Method{
-			Method:      v.Method(i),
-			ServiceType: v,
-		}
+func (svc *Service) AddQueries(input interface{}, opts ...Option) error {
+	methods, err := methods(input, opts, svc.Logger())
+	if err != nil {
+		return err
+	}
+	for _, method := range methods {
 		svc.Queries = append(svc.Queries, &Query{method})
 	}
+	return nil
 }
 
-func (svc *Service) add(t AssetType, input interface{}) {
-
+// methods returns configured methods of input
+func methods(input interface{}, opts []Option, logger *slog.Logger) ([]*Method, error) {
+	o := newOptions(opts)
+	if err := o.mutationOnly(); err != nil {
+		return nil, err
+	}
 	v := reflect.TypeOf(input)
+	factory, err := o.factory(v)
+	if err != nil {
+		return nil, err
+	}
+	middlewares, err := o.middlewareRefs()
+	if err != nil {
+		return nil, err
+	}
+	validator, err := o.validatorRef()
+	if err != nil {
+		return nil, err
+	}
+	if o.transport == "" {
+		o.transport = LambdaTransport
+	}
+	if err := o.transport.validate(); err != nil {
+		return nil, err
+	}
+	result := []*Method{}
+	names := []string{}
 	for i := 0; i < v.NumMethod(); i++ {
+		if isLifecycleHook(v, v.Method(i).Name) {
+			continue
+		}
+		settings, err := o.settings(v.Method(i).Name)
+		if err != nil {
+			return nil, err
+		}
+		if o.instrumentation != "" {
+			if err := o.instrumentation.configure(&settings); err != nil {
+				return nil, err
+			}
+		}
 		method := &Method{
-			Method:      v.Method(i),
-			ServiceType: v,
+			Method:       v.Method(i),
+			ServiceType:  v,
+			Settings:     settings,
+			Factory:      factory,
+			Init:         hasHook(v, "Init"),
+			Close:        hasClose(v),
+			Middlewares:  middlewares,
+			Transport:    o.transport,
+			Instrumented: o.instrumentation != "",
 		}
-		svc.Queries = append(svc.Queries, &Query{method})
+		if err := method.checkSignature(); err != nil {
+			return nil, err
+		}
+		validation(method, validator, logger)
+		result = append(result, method)
+		names = append(names, v.Method(i).Name)
+	}
+	if err := o.unknown(names); err != nil {
+		return nil, err
 	}
+	return result, nil
 }
 
 // AddMutation -
 // TODO: mutation has following structure: OnEvent(context, Event) error
-func (svc *Service) AddMutation(input interface{}) error {
+// Mutation is deployed as a single function, options are applied using
+// WithOptions or WithMethodOptions with mutation type name.
+func (svc *Service) AddMutation(input interface{}, opts ...Option) error {
 
 	if svc.Mutation != nil {
 		return errors.New("mutation already exists")
 	}
 	v := reflect.TypeOf(input)
-	svc.Mutation = &Mutation{
-		ServiceType: v,
-		Methods:     []*Method{},
+	name := elem(v).Name()
+	o := newOptions(opts)
+	if err := o.unknown([]string{name}); err != nil {
+		return err
 	}
-	for i := 0; i < v.NumMethod(); i++ {
-		if !isMutation(v.Method(i)) {
-			continue
+	if len(o.middlewares) > 0 {
+		return errors.New("middleware applies to commands and queries only")
+	}
+	if o.validator != nil {
+		return errors.New("validator applies to commands and queries only")
+	}
+	if o.transport != "" {
+		return errors.New("transport applies to commands and queries only")
+	}
+	settings, err := o.settings(name)
+	if err != nil {
+		return err
+	}
+	if o.deadLetter != nil {
+		if err := o.deadLetter.configure(v, &settings); err != nil {
+			return err
 		}
-		if err := svc.Mutation.Add(v.Method(i)); err != nil {
+	}
+	if o.source == "" {
+		o.source = KinesisSource
+	}
+	if err := o.source.validate(); err != nil {
+		return err
+	}
+	push, err := pushMode(v, o.push)
+	if err != nil {
+		return err
+	}
+	unknown, err := unknownPolicy(v, o.unknownEvents)
+	if err != nil {
+		return err
+	}
+	factory, err := o.factory(v)
+	if err != nil {
+		return err
+	}
+	if o.checkpoint != nil {
+		if err := o.checkpoint.configure(o.source, &settings); err != nil {
 			return err
 		}
 	}
+	if o.instrumentation != "" {
+		if err := o.instrumentation.configure(&settings); err != nil {
+			return err
+		}
+	}
+	mutation := &Mutation{
+		ServiceType:  v,
+		Methods:      []*Method{},
+		Settings:     settings,
+		DeadLetter:   o.deadLetter,
+		Source:       o.source,
+		Push:         push,
+		Begin:        hasHook(v, "Begin"),
+		Unknown:      unknown,
+		Checkpoint:   o.checkpoint,
+		Factory:      factory,
+		Init:         hasHook(v, "Init"),
+		Close:        hasClose(v),
+		Instrumented: o.instrumentation != "",
+	}
+	if err := addHandlers(mutation, v, o, svc.Logger()); err != nil {
+		return err
+	}
+	svc.Mutation = mutation
 	return nil
 }
 
@@ -121,7 +245,7 @@ func (svc *Service) Initialize() error {
 	}
 
 	if _, err := os.Stat(dir); !os.IsNotExist(err) {
-		fmt.Println(dir, "exists")
+		svc.Logger().Debug("output directory exists", "dir", dir)
 		return nil
 	}
 
@@ -135,12 +259,20 @@ func (svc *Service) Clean() error {
 	return os.RemoveAll(svc.dir)
 }
 
+// Build builds assets, see BuildContext
 func (svc *Service) Build() error {
+	return svc.BuildContext(context.Background())
+}
+
+// BuildContext builds assets. Canceling ctx stops the build and kills
+// running compiler.
+func (svc *Service) BuildContext(ctx context.Context) error {
 
 	assets := svc.assets()
 
 	p := mpb.New(
 		mpb.WithWidth(60),
+		mpb.WithOutput(svc.progress),
 	)
 
 	bar := p.AddBar(int64(len(assets)), mpb.BarStyle("[=>-|"),
@@ -150,33 +282,53 @@ func (svc *Service) Build() error {
 	)
 
 	for _, asset := range assets {
-		tmpl, err := getTemplate(asset.Type())
-		if err != nil {
+		if err := svc.build(ctx, asset); err != nil {
+			p.Abort(bar, false)
+			p.Wait()
 			return err
 		}
-		fmtMethod, err := generate(tmpl, asset)
-		if err != nil {
-			return err
-		}
-		zipPath, err := buildMain(svc.dir, asset.Key(), fmtMethod)
-		if err != nil {
-			return err
-		}
-		asset.SetBuildPath(zipPath)
 		bar.Increment()
 	}
 	p.Wait()
 	return nil
 }
 
+func (svc *Service) build(ctx context.Context, asset Asset) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	tmpl, err := getTemplate(asset.Type())
+	if err != nil {
+		return err
+	}
+	fmtMethod, err := generate(tmpl, asset)
+	if err != nil {
+		return err
+	}
+	zipPath, err := buildMain(ctx, svc.dir, asset.Key(), fmtMethod)
+	if err != nil {
+		svc.Logger().Debug("build failed", "asset", asset.Name(), "source", fmtMethod)
+		return err
+	}
+	asset.SetBuildPath(zipPath)
+	return nil
+}
+
+// Publish uploads built assets, see PublishContext
 func (svc *Service) Publish() error {
+	return svc.PublishContext(context.Background())
+}
+
+// PublishContext uploads built assets. Canceling ctx aborts running upload.
+func (svc *Service) PublishContext(ctx context.Context) error {
 	assets := svc.assets()
 
 	uploader, err := NewUploader(svc.cfg.Context.Bucket, "assets/")
 	if err != nil {
 		return err
 	}
-	return uploader.Upload(assets)
+	uploader.progress = svc.progress
+	return uploader.UploadContext(ctx, assets)
 }
 
 func (svc *Service) assets() []Asset {
@@ -204,11 +356,15 @@ func (svc *Service) Write(name string) error {
 	if err != nil {
 		return err
 	}
-	if _, err = f.WriteString(svc.String()); err != nil {
+	_, err = f.WriteString(svc.String())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
 		return err
 	}
 
-	color.Yellow("✅ generate output file: %s", p)
+	svc.Logger().Info("generated output file", "path", p)
 	return nil
 }
 
@@ -222,28 +378,35 @@ func (svc *Service) Config() *Config {
 
 	for _, command := range svc.Commands {
 		cfg.Commands = append(cfg.Commands, ConfigMethod{
-			Name:    command.Name(),
-			S3Key:   command.S3Key(),
-			Handler: "main.out",
-			Runtime: "GO1.X",
+			Name:      command.Name(),
+			S3Key:     command.S3Key(),
+			Handler:   "main.out",
+			Runtime:   "GO1.X",
+			Transport: string(command.Transport),
+			Settings:  command.Settings,
 		})
 	}
 
 	for _, query := range svc.Queries {
 		cfg.Queries = append(cfg.Queries, ConfigMethod{
-			Name:    query.Name(),
-			S3Key:   query.S3Key(),
-			Handler: "main.out",
-			Runtime: "GO1.X",
+			Name:      query.Name(),
+			S3Key:     query.S3Key(),
+			Handler:   "main.out",
+			Runtime:   "GO1.X",
+			Transport: string(query.Transport),
+			Settings:  query.Settings,
 		})
 	}
 
 	if svc.Mutation != nil {
 		cfg.Mutation = &ConfigMethod{
-			Name:    "Mutation",
-			S3Key:   svc.Mutation.S3Key(),
-			Handler: "main.out",
-			Runtime: "GO1.X",
+			Name:                    "Mutation",
+			S3Key:                   svc.Mutation.S3Key(),
+			Handler:                 "main.out",
+			Runtime:                 "GO1.X",
+			Source:                  string(svc.Mutation.Source),
+			ReportBatchItemFailures: svc.Mutation.Source.BatchItemFailures(),
+			Settings:                svc.Mutation.Settings,
 		}
 		cfg.Events = svc.Mutation.EventNames()
 	}
@@ -0,0 +1,101 @@
+package gen
+
+import "fmt"
+
+// Source of mutation events
+type Source string
+
+const (
+	KinesisSource     = Source("kinesis")
+	DynamoDBSource    = Source("dynamodb")
+	SQSSource         = Source("sqs")
+	SNSSource         = Source("sns")
+	EventBridgeSource = Source("eventbridge")
+)
+
+type sourceSpec struct {
+	// name of decoder in source package and prefix of batch item failure
+	name string
+	// lambda event type
+	event string
+	// managed policy required to read the source
+	policy string
+	// infrastructure parameter identifying the source
+	param       string
+	description string
+	batch       bool
+}
+
+var sources = map[Source]sourceSpec{
+	KinesisSource: {
+		name:        "Kinesis",
+		event:       "KinesisEvent",
+		policy:      "arn:aws:iam::aws:policy/service-role/AWSLambdaKinesisExecutionRole",
+		param:       "StreamArn",
+		description: "ARN of Kinesis stream consumed by mutation",
+		batch:       true,
+	},
+	DynamoDBSource: {
+		name:        "DynamoDB",
+		event:       "DynamoDBEvent",
+		policy:      "arn:aws:iam::aws:policy/service-role/AWSLambdaDynamoDBExecutionRole",
+		param:       "StreamArn",
+		description: "ARN of DynamoDB stream consumed by mutation",
+		batch:       true,
+	},
+	SQSSource: {
+		name:        "SQS",
+		event:       "SQSEvent",
+		policy:      "arn:aws:iam::aws:policy/service-role/AWSLambdaSQSQueueExecutionRole",
+		param:       "QueueArn",
+		description: "ARN of SQS queue consumed by mutation",
+		batch:       true,
+	},
+	SNSSource: {
+		name:        "SNS",
+		event:       "SNSEvent",
+		param:       "TopicArn",
+		description: "ARN of SNS topic consumed by mutation",
+	},
+	EventBridgeSource: {
+		name:        "EventBridge",
+		event:       "CloudWatchEvent",
+		param:       "EventBusName",
+		description: "Name of EventBridge bus consumed by mutation",
+	},
+}
+
+// WithSource sets source of mutation events, Kinesis is used by default
+func WithSource(source Source) Option {
+	return func(o *options) {
+		o.source = source
+	}
+}
+
+func (s Source) validate() error {
+	if _, ok := sources[s]; !ok {
+		return fmt.Errorf("unknown mutation source %q", s)
+	}
+	return nil
+}
+
+// Name returns name of the source decoder
+func (s Source) Name() string {
+	return sources[s].name
+}
+
+// Event returns lambda event type of the source
+func (s Source) Event() string {
+	return sources[s].event
+}
+
+// BatchItemFailures reports whether the source supports partial batch
+// failures
+func (s Source) BatchItemFailures() bool {
+	return sources[s].batch
+}
+
+// Stream reports whether the source is read from a stream
+func (s Source) Stream() bool {
+	return s == KinesisSource || s == DynamoDBSource
+}
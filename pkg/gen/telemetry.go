@@ -0,0 +1,38 @@
+package gen
+
+import (
+	"fmt"
+
+	"github.com/mrzahrada/gen/pkg/telemetry"
+)
+
+// TelemetryExporter exports spans and metrics of instrumented handlers
+type TelemetryExporter string
+
+const (
+	// EMFExporter writes metrics in CloudWatch embedded metric format and
+	// spans as JSON into function logs
+	EMFExporter = TelemetryExporter("emf")
+	// OTLPExporter sends spans and metrics to OpenTelemetry collector set
+	// by OTEL_EXPORTER_OTLP_ENDPOINT environment variable
+	OTLPExporter = TelemetryExporter("otlp")
+)
+
+// WithInstrumentation records span per invocation of commands, queries
+// and mutation and per event dispatched by mutation, with durations, error
+// counts and batch sizes. Spans and metrics are exported by exporter.
+func WithInstrumentation(exporter TelemetryExporter) Option {
+	return func(o *options) {
+		o.instrumentation = exporter
+	}
+}
+
+// configure adds environment selecting exporter into settings
+func (e TelemetryExporter) configure(settings *Settings) error {
+	switch e {
+	case EMFExporter, OTLPExporter:
+	default:
+		return fmt.Errorf("unknown telemetry exporter %q", e)
+	}
+	return Env(telemetry.EnvExporter, string(e))(settings)
+}
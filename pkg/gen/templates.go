@@ -4,18 +4,45 @@ var resolverTmpl = `// DO NOT EDIT! Generated code
 package main
 
 import (
-	"github.com/aws/aws-lambda-go/lambda"{{ range .Imports}}
-	"{{- .}}"{{end}}
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/lambda"{{ imports }}
 )
 
 func main() {
-	lambda.Start(func() {{ .String }} {
-		svc, err := {{ .PackageName }}.New()
-		if err != nil {
-			panic(err)
+	{{ factory .Factory }}{{ if .Init }}
+	if err := svc.Init({{ pkg "context" }}.Background()); err != nil {
+		{{ pkg "github.com/mrzahrada/gen/pkg/lifecycle" }}.Fail("Init", err)
+	}{{ end }}{{ if .Close }}
+	{{ pkg "github.com/mrzahrada/gen/pkg/lifecycle" }}.OnShutdown(svc.Close){{ end }}{{ if .Instrumented }}
+	provider, err := {{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.FromEnv()
+	if err != nil {
+		{{ pkg "github.com/mrzahrada/gen/pkg/lifecycle" }}.Fail("telemetry.FromEnv", err)
+	}
+	{{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.SetProvider(provider){{ end }}
+	lambda.Start({{ pkg "github.com/mrzahrada/gen/pkg/apierror" }}.{{ .Transport.Adapter }}({{ pkg "github.com/mrzahrada/gen/pkg/middleware" }}.Chain({{ printf "%q" .Name }}, func(ctx {{ pkg "context" }}.Context, payload json.RawMessage) (interface{}, error) {
+	{{- with .Payload }}
+		var input {{ typeName . }}
+		if err := json.Unmarshal(payload, &input); err != nil {
+			return nil, {{ pkg "github.com/mrzahrada/gen/pkg/validate" }}.Invalid(err)
 		}
-		return svc.{{ .Name }}
-	}())
+	{{- if eq .Kind.String "ptr" }}
+		if input == nil {
+			return nil, &{{ pkg "github.com/mrzahrada/gen/pkg/validate" }}.ValidationError{Message: "missing input"}
+		}
+	{{- end }}
+	{{- end }}
+	{{- with .Validator }}
+		if err := {{ pkg .Package }}.{{ .Name }}(&input); err != nil {
+			return nil, {{ pkg "github.com/mrzahrada/gen/pkg/validate" }}.Invalid(err)
+		}
+	{{- end }}
+	{{- if .Validates }}
+		if err := input.Validate(); err != nil {
+			return nil, {{ pkg "github.com/mrzahrada/gen/pkg/validate" }}.Invalid(err)
+		}
+	{{- end }}
+		{{ .Call }}
+	}{{ if .Instrumented }}, {{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.Trace{{ end }}{{ range .Middlewares }}, {{ pkg .Package }}.{{ .Name }}{{ end }})))
 }
 `
 
@@ -35,72 +62,298 @@ var mutationTmpl = `
 // DO NOT EDIT! Generated code.
 package main
 
-import (
-	"log"
-	"github.com/mrzahrada/es"
-    lambdaevents "github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"{{ range .Imports}}
-	"{{- .}}"{{end}}
+import ({{ template "imports" . }}
+	"github.com/aws/aws-lambda-go/lambda"
 )
 
 func main() {
-	svc, err := {{ .PackageName }}.New()
+	{{ template "handler" . }}{{ if .Checkpoint }}
+	checkpoints, err := checkpoint.FromEnv()
 	if err != nil {
-		panic(err)
+		{{ pkg "github.com/mrzahrada/gen/pkg/lifecycle" }}.Fail("checkpoint.FromEnv", err)
 	}
-	lambda.Start(func() func(ctx context.Context, event lambdaevents.KinesisEvent) error {
-		h := handler{
-			svc: svc,
-			es: es.NewUnmarshaler({{range .Events}} 
-				{{.}}{},{{end}}
-			),
-		}
-		return h.on
-	}())
+	h.checkpoints = checkpoints{{ end }}{{ template "init" . }}{{ if .Close }}
+	{{ pkg "github.com/mrzahrada/gen/pkg/lifecycle" }}.OnShutdown(svc.Close){{ end }}
+	lambda.Start(h.on)
 }
-{{ $events := .Events }}	
-type service interface{
-	Push(context.Context) error{{range $index, $element := .EventNames}} 
-	On{{.}}(context.Context, *{{ index $events $index }}) error {{end}}
+{{ template "dispatch" . }}`
+
+// mutationDispatchTmpl is shared by mutation main and mutation test
+// package, so that tests run the deployed dispatch code
+var mutationDispatchTmpl = `
+{{ define "imports" }}
+	"context"{{ if or (not .Source.BatchItemFailures) (eq .Unknown "fail") }}
+	"fmt"{{ end }}{{ if .DeadLetter }}
+	"time"
+	"github.com/mrzahrada/gen/pkg/deadletter"{{ end }}{{ if .Checkpoint }}
+	"github.com/mrzahrada/gen/pkg/checkpoint"{{ end }}
+	"github.com/mrzahrada/gen/pkg/source"
+	"github.com/mrzahrada/es"
+	lambdaevents "github.com/aws/aws-lambda-go/events"{{ imports }}{{ end }}
+
+{{ define "unmarshaler" }}source.NewUnmarshaler(){{ range $method := .Methods }}{{ range $method.EventNames }}.
+			Register({{ printf "%q" . }}, {{ elemName $method.Event }}{}){{ end }}{{ end }}{{ end }}
+
+{{ define "batchSpan" }}{{ if .Instrumented }}
+	ctx, span := {{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.Start(ctx, {{ printf "%q" .Name }},
+		{{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.String("source", {{ printf "%q" .Source }}),
+		{{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.Int("batch.size", len(records)),
+	)
+	{{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.Record(ctx, "BatchSize", {{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.Count, float64(len(records))){{ end }}{{ end }}
+
+{{ define "batchSpanEnd" }}{{ if .Instrumented }}
+	if len(failed) > 0 {
+		span.SetAttributes({{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.String("record.failed", failed[0]))
+	}
+	span.Finish(ctx, err){{ end }}{{ end }}
+
+{{ define "handler" }}{{ factory .Factory }}
+	h := handler{
+		svc: svc,
+		es:  {{ template "unmarshaler" . }},{{ if .DeadLetter }}
+		tracker: {{ template "tracker" . }},{{ end }}
+	}{{ if .DeadLetter }}{{ if .DeadLetter.Custom }}
+	h.sink = svc{{ else }}
+	sink, err := deadletter.FromEnv()
+	if err != nil {
+		{{ pkg "github.com/mrzahrada/gen/pkg/lifecycle" }}.Fail("deadletter.FromEnv", err)
+	}
+	h.sink = sink{{ end }}{{ end }}{{ end }}
+
+{{ define "init" }}{{ if .Init }}
+	if err := svc.Init(context.Background()); err != nil {
+		{{ pkg "github.com/mrzahrada/gen/pkg/lifecycle" }}.Fail("Init", err)
+	}{{ end }}{{ if .Instrumented }}
+	provider, err := {{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.FromEnv()
+	if err != nil {
+		{{ pkg "github.com/mrzahrada/gen/pkg/lifecycle" }}.Fail("telemetry.FromEnv", err)
+	}
+	{{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.SetProvider(provider){{ end }}{{ end }}
+
+{{ define "tracker" }}deadletter.NewTracker({{ .DeadLetter.MaxAttempts }}, time.Duration({{ .DeadLetter.MaxAge.Nanoseconds }})){{ end }}
+
+{{ define "dispatch" }}
+type service interface{ {{- if .Push.Required }}
+	Push(context.Context) error{{ end }}{{ if .Begin }}
+	Begin(context.Context) error{{ end }}{{ if eq .Unknown "route" }}
+	OnUnknown(context.Context, []byte) error{{ end }}{{ range $method := .Methods }}
+	{{ $method.Name }}(context.Context, {{ if $method.Envelope }}source.Envelope, {{ end }}{{ typeName $method.Event }}) error{{end}}
 }
 
 type handler struct {
 	svc service
-	es  *es.Unmarshaler
-}
+	es  *source.Unmarshaler{{ if .DeadLetter }}
 
-func (h handler) on(ctx context.Context, input lambdaevents.KinesisEvent) error {
-
-	for _, record := range input.Records {
-		data := record.Kinesis.Data
-		event, err := h.es.Unmarshal(data)
+	tracker *deadletter.Tracker
+	sink    deadletter.Sink{{ end }}{{ if .Checkpoint }}
 
+	checkpoints checkpoint.Store{{ end }}
+}
+{{ if .Source.BatchItemFailures }}
+// on reports the first failed record and all records after it as batch
+// item failures, so that only unprocessed records are retried.
+func (h handler) on(ctx context.Context, input lambdaevents.{{ .Source.Event }}) (lambdaevents.{{ .Source.Event }}Response, error) {
+	ctx = {{ pkg "github.com/mrzahrada/gen/pkg/middleware" }}.EnsureRequestID(ctx)
+	response := lambdaevents.{{ .Source.Event }}Response{
+		BatchItemFailures: []lambdaevents.{{ .Source.Name }}BatchItemFailure{},
+	}
+	records, err := source.{{ .Source.Name }}(input)
+	if err != nil {
+		return response, err
+	}{{ template "batchSpan" . }}
+	failed, err := h.process(ctx, records){{ template "batchSpanEnd" . }}
+	for _, id := range failed {
+		response.BatchItemFailures = append(response.BatchItemFailures, lambdaevents.{{ .Source.Name }}BatchItemFailure{
+			ItemIdentifier: id,
+		})
+	}
+	return response, err
+}
+{{ else }}
+func (h handler) on(ctx context.Context, input lambdaevents.{{ .Source.Event }}) error {
+	ctx = {{ pkg "github.com/mrzahrada/gen/pkg/middleware" }}.EnsureRequestID(ctx)
+	records, err := source.{{ .Source.Name }}(input)
+	if err != nil {
+		return err
+	}{{ template "batchSpan" . }}
+	failed, err := h.process(ctx, records){{ template "batchSpanEnd" . }}
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("record %s failed", failed[0])
+	}
+	return nil
+}
+{{ end }}
+// process handles records in order and returns IDs of the first failed
+// record and of all records after it. Records processed before the
+// failure are pushed, records not pushed are returned as failed, all
+// records when push of the batch fails.
+func (h handler) process(ctx context.Context, records []source.Record) ([]string, error) {
+{{- if .Begin }}
+	if err := h.svc.Begin(ctx); err != nil {
+		return nil, err
+	}
+{{- end }}
+{{- if .Checkpoint }}
+	checkpoints := map[checkpoint.Key]string{}
+	for _, record := range records {
+		key := checkpoint.Key{Stream: record.Stream, Shard: record.Shard}
+		if _, ok := checkpoints[key]; ok {
+			continue
+		}
+		sequence, err := h.checkpoints.Get(ctx, key)
 		if err != nil {
-			if err == es.ErrUnknownEventType {
-				log.Printf("uknown event: %s", string(data))
-				continue
+			return nil, err
+		}
+		checkpoints[key] = sequence
+	}
+	// positions of records processed since the last commit
+	positions := map[checkpoint.Key]string{}
+{{- end }}
+	// failed is index of the first failed record
+	failed := len(records)
+{{- if .Push.Every }}
+	// pending is index of the first record processed since the last push
+	pending, processed := -1, 0
+{{- end }}
+	for i, record := range records {
+{{- if .Checkpoint }}
+		key := checkpoint.Key{Stream: record.Stream, Shard: record.Shard}
+		if checkpoint.Compare(record.ID, checkpoints[key]) <= 0 {
+			continue
+		}
+{{- end }}
+		if err := h.handle(ctx, record); err != nil {
+			{{ pkg "github.com/mrzahrada/gen/pkg/logging" }}.FromContext(ctx).Error("record failed", "id", record.ID, "error", err)
+			failed = i
+			break
+		}
+{{- if .Checkpoint }}
+		positions[key] = record.ID
+{{- end }}
+{{- if .Push.Every }}
+
+		if pending < 0 {
+			pending = i
+		}
+		processed++
+		if processed%{{ .Push.Every }} == 0 {
+			if err := h.svc.Push(ctx); err != nil {
+				{{ pkg "github.com/mrzahrada/gen/pkg/logging" }}.FromContext(ctx).Error("push failed", "error", err)
+				return source.IDs(records[pending:]), nil
 			}
-			return err
+			pending = -1
+{{- if .Checkpoint }}
+			h.commit(ctx, positions)
+{{- end }}
 		}
-		if err := h.call(ctx, event); err != nil {
-			return err
+{{- end }}
+	}
+{{- if .Push.Batch }}
+	if err := h.svc.Push(ctx); err != nil {
+		return source.IDs(records), err
+	}
+{{- else if .Push.Every }}
+	if pending >= 0 {
+		if err := h.svc.Push(ctx); err != nil {
+			{{ pkg "github.com/mrzahrada/gen/pkg/logging" }}.FromContext(ctx).Error("push failed", "error", err)
+			return source.IDs(records[pending:]), nil
 		}
 	}
-	return h.svc.Push(ctx)
+{{- end }}
+{{- if .Checkpoint }}
+	h.commit(ctx, positions)
+{{- end }}
+	return source.IDs(records[failed:]), nil
 }
 
-func (h handler) call(ctx context.Context, input interface{}) error {
-	var err error
-	switch v := input.(type) { {{range $index, $element := .EventNames}} 
-    case *{{ index $events $index }}:
-        err = h.svc.On{{$element}}(ctx, v){{end}}
+// handle dispatches event of record, quarantined record is handled
+func (h handler) handle(ctx context.Context, record source.Record) error {
+	event, err := h.es.Unmarshal(record.Data)
+	switch err {
+	case nil:
+		err = h.call(ctx, record, event)
+	case es.ErrUnknownEventType:
+		err = h.unknown(ctx, record)
+	}
+{{- if .DeadLetter }}
+	if err != nil {
+		if h.quarantine(ctx, record, err) {
+			return nil
+		}
+		return err
+	}
+	h.tracker.Done(record.ID)
+{{- end }}
+	return err
+}
+{{ if .Checkpoint }}
+// commit stores positions of pushed records. Failed commit leads only to
+// repeated processing, so it does not fail the batch.
+func (h handler) commit(ctx context.Context, positions map[checkpoint.Key]string) {
+	for key, sequence := range positions {
+		if err := h.checkpoints.Put(ctx, key, sequence); err != nil {
+			{{ pkg "github.com/mrzahrada/gen/pkg/logging" }}.FromContext(ctx).Error("checkpoint not committed", "sequence", sequence, "stream", key.Stream, "shard", key.Shard, "error", err)
+		}
+		delete(positions, key)
+	}
+}
+{{ end }}{{ if .DeadLetter }}
+// quarantine writes record into dead letter sink once it failed too many
+// times or is too old. Quarantined record is skipped.
+func (h handler) quarantine(ctx context.Context, record source.Record, cause error) bool {
+	attempts, ok := h.tracker.Fail(record.ID, record.Arrived)
+	if !ok {
+		return false
+	}
+
+	letter := deadletter.Letter{
+		ID:       record.ID,
+		Data:     string(record.Data),
+		Error:    cause.Error(),
+		Attempts: attempts,
+		Arrived:  record.Arrived,
+		At:       time.Now().UTC(),
+	}
+	if err := h.sink.PutDeadLetter(ctx, letter); err != nil {
+		{{ pkg "github.com/mrzahrada/gen/pkg/logging" }}.FromContext(ctx).Error("record not quarantined", "id", record.ID, "error", err)
+		return false
+	}
+	h.tracker.Done(record.ID)
+	{{ pkg "github.com/mrzahrada/gen/pkg/logging" }}.FromContext(ctx).Warn("record quarantined", "id", record.ID, "attempts", attempts, "error", cause)
+	return true
+}
+{{ end }}
+func (h handler) call(ctx context.Context, record source.Record, input interface{}) error {
+	var err error{{ if .Instrumented }}
+	ctx, span := {{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.Start(ctx, record.EventType(),
+		{{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.String("event.type", record.EventType()),
+		{{ pkg "github.com/mrzahrada/gen/pkg/telemetry" }}.String("record.id", record.ID),
+	)
+	defer func() {
+		span.Finish(ctx, err)
+	}(){{ end }}
+	switch v := input.(type) { {{ range $method := .Methods }}
+	case *{{ elemName $method.Event }}:
+		err = h.svc.{{ $method.Name }}(ctx, {{ if $method.Envelope }}record.Envelope(), {{ end }}{{ if ne $method.Event.Kind.String "ptr" }}*{{ end }}v){{end}}
 	default:
-		// log.Printf("[ERROR] missing handler for %+v. event: %+v", v, input)
+		err = h.unknown(ctx, record)
 	}
 
 	return err
-	// {{ .EventNames}}
 }
+
+// unknown handles record of event type without handler
+func (h handler) unknown(ctx context.Context, record source.Record) error { {{- if eq .Unknown "ignore" }}
+	return nil{{ else if eq .Unknown "log" }}
+	{{ pkg "github.com/mrzahrada/gen/pkg/logging" }}.FromContext(ctx).Warn("unknown event", "id", record.ID, "type", record.EventType(), "data", string(record.Data))
+	return nil{{ else if eq .Unknown "fail" }}
+	return fmt.Errorf("unknown event type %q", record.EventType()){{ else }}
+	return h.svc.OnUnknown(ctx, record.Data){{ end }}
+}
+{{ end }}
 `
 
 var mutationTmplOld = `
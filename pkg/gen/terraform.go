@@ -0,0 +1,269 @@
+package gen
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+type tfObject map[string]interface{}
+
+// Terraform returns Terraform JSON configuration (*.tf.json) with a
+// function, role and output per command, query and function. Commands and
+// queries with HTTP transport are routed by HTTP API. Mutation is
+// subscribed to its source passed as a variable.
+func (cfg *Config) Terraform() ([]byte, error) {
+	variables := tfObject{}
+	resources := tfObject{}
+	outputs := tfObject{}
+
+	for _, fn := range cfg.lambdas() {
+		id := snakeCase(fn.ID())
+		name := fn.FunctionName(cfg.ServiceName)
+
+		resources.add("aws_iam_role", id, tfObject{
+			"name":               name,
+			"assume_role_policy": "${data.aws_iam_policy_document.lambda_assume_role.json}",
+		})
+		for _, arn := range fn.managedPolicies() {
+			resources.add("aws_iam_role_policy_attachment", id+"_"+snakeCase(arn[strings.LastIndex(arn, "/")+1:]), tfObject{
+				"role":       "${aws_iam_role." + id + ".name}",
+				"policy_arn": arn,
+			})
+		}
+		if len(fn.Method.Policies) > 0 {
+			statements := []tfObject{}
+			for _, policy := range fn.Method.Policies {
+				statements = append(statements, tfObject{
+					"Effect":   policy.Effect,
+					"Action":   policy.Actions,
+					"Resource": policy.Resources,
+				})
+			}
+			document, err := json.Marshal(tfObject{
+				"Version":   "2012-10-17",
+				"Statement": statements,
+			})
+			if err != nil {
+				return nil, err
+			}
+			resources.add("aws_iam_role_policy", id, tfObject{
+				"name":   name,
+				"role":   "${aws_iam_role." + id + ".id}",
+				"policy": string(document),
+			})
+		}
+
+		function := tfObject{
+			"function_name": name,
+			"s3_bucket":     cfg.Bucket,
+			"s3_key":        fn.Method.S3Key,
+			"handler":       fn.Method.Handler,
+			"runtime":       strings.ToLower(fn.Method.Runtime),
+			"role":          "${aws_iam_role." + id + ".arn}",
+		}
+		settings := fn.Method.Settings
+		if settings.MemorySize > 0 {
+			function["memory_size"] = settings.MemorySize
+		}
+		if settings.Timeout > 0 {
+			function["timeout"] = settings.Timeout
+		}
+		if settings.ReservedConcurrency != nil {
+			function["reserved_concurrent_executions"] = *settings.ReservedConcurrency
+		}
+		if len(settings.Environment) > 0 {
+			function["environment"] = tfObject{
+				"variables": settings.Environment,
+			}
+		}
+		if settings.VPC != nil {
+			function["vpc_config"] = tfObject{
+				"subnet_ids":         settings.VPC.SubnetIDs,
+				"security_group_ids": settings.VPC.SecurityGroupIDs,
+			}
+		}
+		resources.add("aws_lambda_function", id, function)
+
+		outputs[id+"_arn"] = tfObject{
+			"value": "${aws_lambda_function." + id + ".arn}",
+		}
+
+		if fn.Route() != "" {
+			cfg.tfRoute(resources, outputs, fn)
+		}
+		if fn.Kind == MutationType {
+			if err := cfg.tfSource(variables, resources, fn); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := tfObject{
+		"data": tfObject{
+			"aws_iam_policy_document": tfObject{
+				"lambda_assume_role": tfObject{
+					"statement": []tfObject{{
+						"actions": []string{"sts:AssumeRole"},
+						"principals": []tfObject{{
+							"type":        "Service",
+							"identifiers": []string{"lambda.amazonaws.com"},
+						}},
+					}},
+				},
+			},
+		},
+		"resource": resources,
+	}
+	if len(variables) > 0 {
+		result["variable"] = variables
+	}
+	if len(outputs) > 0 {
+		result["output"] = outputs
+	}
+
+	return json.MarshalIndent(result, "", "  ")
+}
+
+// add resource of type typ
+func (resources tfObject) add(typ, name string, resource tfObject) {
+	if _, ok := resources[typ]; !ok {
+		resources[typ] = tfObject{}
+	}
+	resources[typ].(tfObject)[name] = resource
+}
+
+// tfRoute routes HTTP API requests to fn, API is created with the first
+// route
+func (cfg *Config) tfRoute(resources, outputs tfObject, fn lambdaFunction) {
+	id := snakeCase(fn.ID())
+	if _, ok := resources["aws_apigatewayv2_api"]; !ok {
+		resources.add("aws_apigatewayv2_api", "http_api", tfObject{
+			"name":          cfg.ServiceName,
+			"protocol_type": "HTTP",
+		})
+		resources.add("aws_apigatewayv2_stage", "http_api", tfObject{
+			"api_id":      "${aws_apigatewayv2_api.http_api.id}",
+			"name":        "$default",
+			"auto_deploy": true,
+		})
+		outputs["http_api_url"] = tfObject{
+			"value": "${aws_apigatewayv2_api.http_api.api_endpoint}",
+		}
+	}
+
+	resources.add("aws_apigatewayv2_integration", id, tfObject{
+		"api_id":                 "${aws_apigatewayv2_api.http_api.id}",
+		"integration_type":       "AWS_PROXY",
+		"integration_uri":        "${aws_lambda_function." + id + ".arn}",
+		"payload_format_version": "2.0",
+	})
+	resources.add("aws_apigatewayv2_route", id, tfObject{
+		"api_id":    "${aws_apigatewayv2_api.http_api.id}",
+		"route_key": fn.Route(),
+		"target":    "integrations/${aws_apigatewayv2_integration." + id + ".id}",
+	})
+	resources.add("aws_lambda_permission", id, tfObject{
+		"action":        "lambda:InvokeFunction",
+		"function_name": "${aws_lambda_function." + id + ".function_name}",
+		"principal":     "apigateway.amazonaws.com",
+		"source_arn":    "${aws_apigatewayv2_api.http_api.execution_arn}/*/*",
+	})
+}
+
+// tfSource subscribes mutation fn to its source
+func (cfg *Config) tfSource(variables, resources tfObject, fn lambdaFunction) error {
+	id := snakeCase(fn.ID())
+	source := fn.source()
+	spec := sources[source]
+	variable := snakeCase(spec.param)
+	arn := "${aws_lambda_function." + id + ".arn}"
+
+	declaration := tfObject{
+		"type":        "string",
+		"description": spec.description,
+	}
+	variables[variable] = declaration
+
+	switch source {
+	case SNSSource:
+		resources.add("aws_sns_topic_subscription", id, tfObject{
+			"topic_arn": "${var." + variable + "}",
+			"protocol":  "lambda",
+			"endpoint":  arn,
+		})
+		resources.add("aws_lambda_permission", id, tfObject{
+			"action":        "lambda:InvokeFunction",
+			"function_name": "${aws_lambda_function." + id + ".function_name}",
+			"principal":     "sns.amazonaws.com",
+			"source_arn":    "${var." + variable + "}",
+		})
+	case EventBridgeSource:
+		declaration["default"] = "default"
+		pattern, err := json.Marshal(tfObject{
+			"detail-type": cfg.Events,
+		})
+		if err != nil {
+			return err
+		}
+		resources.add("aws_cloudwatch_event_rule", id, tfObject{
+			"name":           fn.FunctionName(cfg.ServiceName),
+			"event_bus_name": "${var." + variable + "}",
+			"event_pattern":  string(pattern),
+		})
+		resources.add("aws_cloudwatch_event_target", id, tfObject{
+			"rule":           "${aws_cloudwatch_event_rule." + id + ".name}",
+			"event_bus_name": "${var." + variable + "}",
+			"arn":            arn,
+		})
+		resources.add("aws_lambda_permission", id, tfObject{
+			"action":        "lambda:InvokeFunction",
+			"function_name": "${aws_lambda_function." + id + ".function_name}",
+			"principal":     "events.amazonaws.com",
+			"source_arn":    "${aws_cloudwatch_event_rule." + id + ".arn}",
+		})
+	default:
+		mapping := tfObject{
+			"event_source_arn": "${var." + variable + "}",
+			"function_name":    arn,
+		}
+		if source.Stream() {
+			mapping["starting_position"] = "TRIM_HORIZON"
+		}
+		if fn.Method.ReportBatchItemFailures {
+			mapping["function_response_types"] = []string{"ReportBatchItemFailures"}
+		}
+		resources.add("aws_lambda_event_source_mapping", id, mapping)
+	}
+	return nil
+}
+
+// WriteTerraform writes Terraform JSON configuration into file p
+func (svc *Service) WriteTerraform(p string) error {
+	data, err := svc.Config().Terraform()
+	if err != nil {
+		return err
+	}
+	if err := writeFile(p, string(data)); err != nil {
+		return err
+	}
+	svc.Logger().Info("generated terraform configuration", "path", p)
+	return nil
+}
+
+// snakeCase converts CamelCase identifier into snake_case
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
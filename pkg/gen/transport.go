@@ -0,0 +1,43 @@
+package gen
+
+import "fmt"
+
+// Transport of command and query requests, errors returned by services
+// are serialized by transport
+type Transport string
+
+const (
+	// LambdaTransport is direct invocation, default
+	LambdaTransport = Transport("lambda")
+	// HTTPTransport is HTTP API or function URL
+	HTTPTransport = Transport("http")
+	// GraphQLTransport is GraphQL resolver, e.g. AppSync
+	GraphQLTransport = Transport("graphql")
+)
+
+// transports are functions of apierror package serving transport
+var transports = map[Transport]string{
+	LambdaTransport:  "Lambda",
+	HTTPTransport:    "HTTP",
+	GraphQLTransport: "GraphQL",
+}
+
+// WithTransport sets transport of commands and queries, direct Lambda
+// invocation is used by default
+func WithTransport(transport Transport) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+func (t Transport) validate() error {
+	if _, ok := transports[t]; !ok {
+		return fmt.Errorf("unknown transport %q", t)
+	}
+	return nil
+}
+
+// Adapter returns function of apierror package serving the transport
+func (t Transport) Adapter() string {
+	return transports[t]
+}
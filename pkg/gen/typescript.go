@@ -0,0 +1,480 @@
+package gen
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	errorType         = reflect.TypeOf((*error)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typescript renders TypeScript declarations for Go types
+type typescript struct {
+	names map[reflect.Type]string
+	taken map[string]reflect.Type
+	order []reflect.Type
+	enums map[string]map[string][]string
+}
+
+func newTypeScript() *typescript {
+	return &typescript{
+		names: map[reflect.Type]string{},
+		taken: map[string]reflect.Type{},
+		enums: map[string]map[string][]string{},
+	}
+}
+
+// TypeScript returns a TypeScript module with interfaces for every type
+// reachable from commands, queries and mutation events and a fetch based
+// client for commands and queries. The client calls routes POST
+// commands/<Name> and queries/<Name>, which HTTP API created by CDK,
+// CloudFormation and Terraform output serves for HTTP transport. Methods
+// with the default Lambda transport are called through baseUrl routing
+// requests to them, methods with GraphQL transport are left to GraphQL
+// clients. Client method is named after its command or query, suffixed by
+// Command or Query when the name is taken.
+func (svc *Service) TypeScript() string {
+	ts := newTypeScript()
+
+	routed := []clientMethod{}
+	for _, cmd := range svc.Commands {
+		routed = append(routed, clientMethod{"commands", "Command", cmd.Method})
+	}
+	for _, q := range svc.Queries {
+		routed = append(routed, clientMethod{"queries", "Query", q.Method})
+	}
+	taken := map[string]int{}
+	for _, name := range tsClientMembers {
+		taken[name]++
+	}
+	for _, cm := range routed {
+		if cm.fetched() {
+			taken[cm.name()]++
+		}
+	}
+
+	var client strings.Builder
+	client.WriteString(tsClient)
+	for _, cm := range routed {
+		input, output := ts.signature(cm.Method)
+		if !cm.fetched() {
+			continue
+		}
+		name := cm.name()
+		if taken[name] > 1 {
+			name += cm.suffix
+		}
+		cm.write(&client, name, input, output)
+	}
+	client.WriteString("}\n")
+
+	events := []string{}
+	if svc.Mutation != nil {
+		for _, method := range svc.Mutation.Methods {
+			event := method.Event
+			if event.Kind() == reflect.Ptr {
+				event = event.Elem()
+			}
+			for _, name := range method.EventNames {
+				events = append(events, fmt.Sprintf("{ t: %q; d: %s }", name, ts.typeOf(event)))
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("// DO NOT EDIT! Generated code\n")
+	for i := 0; i < len(ts.order); i++ {
+		b.WriteString("\n")
+		ts.declare(&b, ts.order[i])
+	}
+	if len(events) > 0 {
+		fmt.Fprintf(&b, "\nexport type EventEnvelope =\n  | %s;\n", strings.Join(events, "\n  | "))
+	}
+	b.WriteString("\n")
+	b.WriteString(client.String())
+	return b.String()
+}
+
+// WriteTypeScript writes TypeScript module into file p
+func (svc *Service) WriteTypeScript(p string) error {
+	if err := writeFile(p, svc.TypeScript()); err != nil {
+		return err
+	}
+	svc.Logger().Info("generated typescript file", "path", p)
+	return nil
+}
+
+var tsClient = `export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly code: string,
+    message: string,
+    readonly details?: Record<string, unknown>,
+  ) {
+    super(message);
+  }
+}
+
+export class Client {
+  constructor(
+    private readonly baseUrl: string,
+    private readonly fetchFn: typeof fetch = fetch,
+  ) {}
+
+  private async call<T>(path: string, input: unknown): Promise<T> {
+    const fetchFn = this.fetchFn;
+    const response = await fetchFn(` + "`${this.baseUrl}/${path}`" + `, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify(input),
+    });
+    if (!response.ok) {
+      const text = await response.text();
+      let body: { code?: string; message?: string; details?: Record<string, unknown> } = {};
+      try {
+        body = JSON.parse(text);
+      } catch {
+        // not an API error
+      }
+      throw new ApiError(
+        response.status,
+        body.code ?? "UNKNOWN",
+        body.message ?? ` + "`${path}: ${response.status} ${text}`" + `,
+        body.details,
+      );
+    }
+    return (await response.json()) as T;
+  }
+`
+
+// tsClientMembers are members of the client class which methods must not
+// shadow
+var tsClientMembers = []string{"constructor", "baseUrl", "fetchFn", "call"}
+
+// clientMethod is a command or query called by the TypeScript client
+type clientMethod struct {
+	prefix string
+	suffix string
+	*Method
+}
+
+// fetched reports whether the client calls cm, GraphQL resolvers are not
+// called by fetch
+func (cm clientMethod) fetched() bool {
+	return cm.Transport != GraphQLTransport
+}
+
+// name returns client method name, the method name in lower camel case
+func (cm clientMethod) name() string {
+	name := []rune(cm.Name())
+	name[0] = unicode.ToLower(name[0])
+	return string(name)
+}
+
+func (cm clientMethod) write(b *strings.Builder, name, input, output string) {
+	route := cm.prefix + "/" + cm.Name()
+	if input == "" {
+		fmt.Fprintf(b, "\n  %s(): Promise<%s> {\n    return this.call(%q, {});\n  }\n",
+			name, output, route)
+		return
+	}
+	fmt.Fprintf(b, "\n  %s(input: %s): Promise<%s> {\n    return this.call(%q, input);\n  }\n",
+		name, input, output, route)
+}
+
+// signature returns TypeScript types of input and output of m, input is
+// empty when m has none
+func (ts *typescript) signature(m *Method) (string, string) {
+	input, output := "", "void"
+	for _, in := range m.Inputs() {
+		if isContext(in) {
+			continue
+		}
+		input = ts.typeOf(in)
+	}
+	for _, out := range m.Outputs() {
+		if out == errorType {
+			continue
+		}
+		output = ts.typeOf(out)
+	}
+	return input, output
+}
+
+// typeOf returns TypeScript type expression of t. Named structs and enums
+// are registered for declaration.
+func (ts *typescript) typeOf(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		return ts.typeOf(t.Elem())
+	}
+
+	switch {
+	case t == timeType:
+		return "string"
+	case t == rawMessageType:
+		return "unknown"
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		return "unknown"
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		if t.Name() != "" && len(ts.constants(t)) > 0 {
+			return ts.register(t)
+		}
+		return "string"
+	case reflect.Slice, reflect.Array:
+		// byte slices are base64 strings, byte arrays are number arrays
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		elem := ts.typeOf(t.Elem())
+		if strings.ContainsAny(elem, " |") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case reflect.Map:
+		return fmt.Sprintf("{ [key: string]: %s }", ts.typeOf(t.Elem()))
+	case reflect.Struct:
+		if t.Name() == "" {
+			var b strings.Builder
+			b.WriteString("{")
+			for _, field := range ts.fields(t) {
+				b.WriteString(" " + field + ";")
+			}
+			b.WriteString(" }")
+			return b.String()
+		}
+		return ts.register(t)
+	}
+	return "unknown"
+}
+
+// register assigns unique TypeScript name to named type t
+func (ts *typescript) register(t reflect.Type) string {
+	if name, ok := ts.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, ok := ts.taken[name]; ok {
+		pkg := []rune(path.Base(t.PkgPath()))
+		pkg[0] = unicode.ToUpper(pkg[0])
+		name = string(pkg) + name
+	}
+	for i := 2; ts.taken[name] != nil; i++ {
+		name = fmt.Sprintf("%s%d", t.Name(), i)
+	}
+	ts.names[t] = name
+	ts.taken[name] = t
+	ts.order = append(ts.order, t)
+	return name
+}
+
+func (ts *typescript) declare(b *strings.Builder, t reflect.Type) {
+	name := ts.names[t]
+
+	if t.Kind() == reflect.String {
+		values := []string{}
+		for _, c := range ts.constants(t) {
+			values = append(values, strconv.Quote(c))
+		}
+		fmt.Fprintf(b, "export type %s = %s;\n", name, strings.Join(values, " | "))
+		return
+	}
+
+	extends := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if _, ok := jsonName(field); !field.Anonymous || ok || field.Tag.Get("json") == "-" {
+			continue
+		}
+		embedded := field.Type
+		if embedded.Kind() == reflect.Ptr {
+			embedded = embedded.Elem()
+		}
+		if embedded.Kind() == reflect.Struct && embedded.Name() != "" {
+			extends = append(extends, ts.typeOf(embedded))
+		}
+	}
+
+	b.WriteString("export interface " + name)
+	if len(extends) > 0 {
+		b.WriteString(" extends " + strings.Join(extends, ", "))
+	}
+	b.WriteString(" {\n")
+	for _, field := range ts.fields(t) {
+		b.WriteString("  " + field + ";\n")
+	}
+	b.WriteString("}\n")
+}
+
+// fields returns TypeScript property signatures of struct t following
+// encoding/json naming rules. Untagged embedded structs are left out and
+// declared using extends.
+func (ts *typescript) fields(t reflect.Type) []string {
+	result := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, tagged := jsonName(field)
+		if field.Anonymous && !tagged {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if embedded.Name() == "" {
+					result = append(result, ts.fields(embedded)...)
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		typ := ts.typeOf(field.Type)
+		if strings.Contains(tag, ",string") {
+			typ = "string"
+		}
+		optional := ""
+		if strings.Contains(tag, ",omitempty") {
+			optional = "?"
+		}
+		if field.Type.Kind() == reflect.Ptr {
+			optional = "?"
+			typ += " | null"
+		}
+		if !isIdentifier(name) {
+			name = strconv.Quote(name)
+		}
+		result = append(result, fmt.Sprintf("%s%s: %s", name, optional, typ))
+	}
+	return result
+}
+
+// constants returns values of string constants declared with type t in
+// its package source.
+func (ts *typescript) constants(t reflect.Type) []string {
+	pkg, ok := ts.enums[t.PkgPath()]
+	if !ok {
+		pkg = parseConstants(t.PkgPath())
+		ts.enums[t.PkgPath()] = pkg
+	}
+	return pkg[t.Name()]
+}
+
+func parseConstants(pkgPath string) map[string][]string {
+	result := map[string][]string{}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return result
+	}
+	pkg, err := build.Import(pkgPath, wd, 0)
+	if err != nil {
+		return result
+	}
+
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		f, err := parser.ParseFile(fset, path.Join(pkg.Dir, name), nil, 0)
+		if err != nil {
+			continue
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				value := spec.(*ast.ValueSpec)
+				for _, expr := range value.Values {
+					typ, lit := constant(value.Type, expr)
+					if typ == "" || lit == nil || lit.Kind != token.STRING {
+						continue
+					}
+					if s, err := strconv.Unquote(lit.Value); err == nil {
+						result[typ] = append(result[typ], s)
+					}
+				}
+			}
+		}
+	}
+	for typ := range result {
+		sort.Strings(result[typ])
+	}
+	return result
+}
+
+// constant matches `Name Type = "value"` and `Name = Type("value")`
+func constant(typ ast.Expr, expr ast.Expr) (string, *ast.BasicLit) {
+	if ident, ok := typ.(*ast.Ident); ok {
+		lit, _ := expr.(*ast.BasicLit)
+		return ident.Name, lit
+	}
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return "", nil
+	}
+	ident, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return "", nil
+	}
+	lit, _ := call.Args[0].(*ast.BasicLit)
+	return ident.Name, lit
+}
+
+// jsonName returns field name used by encoding/json and whether it was
+// set by a tag
+func jsonName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return field.Name, false
+	}
+	if i := strings.Index(tag, ","); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag != "" {
+		return tag, true
+	}
+	return field.Name, false
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
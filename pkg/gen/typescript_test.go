@@ -0,0 +1,107 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/commands"
+	"github.com/mrzahrada/gen/pkg/gen/internal/fixture/queries"
+)
+
+// newTestService returns service without cdk.json
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	return &Service{
+		Commands: []*Command{},
+		Queries:  []*Query{},
+		dir:      t.TempDir(),
+		cfg:      &CDKConfig{Context: CDKContext{Name: "shop", Bucket: "assets"}},
+	}
+}
+
+func TestTypeScript(t *testing.T) {
+	svc := newTestService(t)
+	if err := svc.AddCommands(&commands.Service{}, WithTransport(HTTPTransport)); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddQueries(&queries.Service{}, WithTransport(HTTPTransport)); err != nil {
+		t.Fatal(err)
+	}
+	ts := svc.TypeScript()
+
+	for _, want := range []string{
+		"export interface CreateInput extends Meta {\n",
+		"  hash: number[];\n",
+		"  data?: string;\n",
+		"  create(input: CreateInput): Promise<Item> {\n",
+		"  getCommand(input: GetInput): Promise<Item> {\n",
+		"    return this.call(\"commands/Get\", input);\n",
+		"  getQuery(input: GetInput): Promise<Item> {\n",
+		"    return this.call(\"queries/Get\", input);\n",
+		"  callCommand(): Promise<void> {\n",
+		"  list(): Promise<Item[]> {\n",
+	} {
+		if !strings.Contains(ts, want) {
+			t.Errorf("missing %q in:\n%s", want, ts)
+		}
+	}
+	for _, unwanted := range []string{"Hidden", "  get(", "  call(): "} {
+		if strings.Contains(ts, unwanted) {
+			t.Errorf("unexpected %q in:\n%s", unwanted, ts)
+		}
+	}
+}
+
+func TestTypeScriptDefaultTransport(t *testing.T) {
+	svc := newTestService(t)
+	if err := svc.AddCommands(&commands.Service{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddQueries(&queries.Service{}, WithTransport(GraphQLTransport)); err != nil {
+		t.Fatal(err)
+	}
+	ts := svc.TypeScript()
+	for _, want := range []string{
+		"export interface CreateInput",
+		"  create(input: CreateInput): Promise<Item> {\n",
+		"  get(input: GetInput): Promise<Item> {\n",
+	} {
+		if !strings.Contains(ts, want) {
+			t.Errorf("missing %q in:\n%s", want, ts)
+		}
+	}
+	if strings.Contains(ts, "list(") || strings.Contains(ts, "queries/") {
+		t.Errorf("client calls GraphQL query:\n%s", ts)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	svc := newTestService(t)
+	if err := svc.AddCommands(&commands.Service{}, WithTransport(HTTPTransport)); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.AddQueries(&queries.Service{}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := svc.Config()
+
+	cfn, err := cfg.CloudFormation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf, err := cfg.Terraform()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, route := range []string{"POST /commands/Create", "POST /commands/Get", "POST /commands/Call"} {
+		if !strings.Contains(string(cfn), `"RouteKey": "`+route+`"`) {
+			t.Errorf("cloudformation misses route %s", route)
+		}
+		if !strings.Contains(string(tf), `"route_key": "`+route+`"`) {
+			t.Errorf("terraform misses route %s", route)
+		}
+	}
+	if strings.Contains(string(cfn), "/queries/") || strings.Contains(string(tf), "/queries/") {
+		t.Error("queries with lambda transport are routed")
+	}
+}
@@ -0,0 +1,62 @@
+package gen
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// UnknownPolicy configures handling of events without handler in mutation
+type UnknownPolicy string
+
+const (
+	// UnknownIgnore skips unknown events
+	UnknownIgnore = UnknownPolicy("ignore")
+	// UnknownLog skips unknown events and logs them with record details
+	UnknownLog = UnknownPolicy("log")
+	// UnknownFail fails the record with unknown event
+	UnknownFail = UnknownPolicy("fail")
+	// UnknownRoute passes unknown events to OnUnknown(ctx, []byte) error
+	UnknownRoute = UnknownPolicy("route")
+)
+
+// WithUnknownEvents sets handling of unknown events. By default events are
+// routed to OnUnknown when mutation implements it and logged otherwise.
+func WithUnknownEvents(policy UnknownPolicy) Option {
+	return func(o *options) {
+		o.unknownEvents = policy
+	}
+}
+
+// unknownPolicy returns validated unknown events policy of mutation t
+func unknownPolicy(t reflect.Type, policy UnknownPolicy) (UnknownPolicy, error) {
+	route := hasOnUnknown(t)
+	switch policy {
+	case "":
+		if route {
+			return UnknownRoute, nil
+		}
+		return UnknownLog, nil
+	case UnknownRoute:
+		if !route {
+			return policy, fmt.Errorf("%s does not implement OnUnknown(context.Context, []byte) error", t)
+		}
+		return policy, nil
+	case UnknownIgnore, UnknownLog, UnknownFail:
+		return policy, nil
+	}
+	return policy, fmt.Errorf("unknown events policy %q", policy)
+}
+
+// hasOnUnknown reports whether t has method OnUnknown(context.Context, []byte) error
+func hasOnUnknown(t reflect.Type) bool {
+	method, ok := t.MethodByName("OnUnknown")
+	if !ok {
+		return false
+	}
+	// first input is the receiver
+	return method.Type.NumIn() == 3 && method.Type.NumOut() == 1 &&
+		method.Type.In(1) == reflect.TypeOf((*context.Context)(nil)).Elem() &&
+		method.Type.In(2) == reflect.TypeOf([]byte(nil)) &&
+		method.Type.Out(0) == errorType
+}
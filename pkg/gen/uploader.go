@@ -1,6 +1,7 @@
 package gen
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -18,6 +19,8 @@ type Uploader struct {
 	prefix string
 	bucket string
 	client *s3manager.Uploader
+	// progress is output of progress bars, nil is stdout
+	progress io.Writer
 }
 
 func NewUploader(bucket string, prefix string) (*Uploader, error) {
@@ -34,10 +37,18 @@ func NewUploader(bucket string, prefix string) (*Uploader, error) {
 	}, nil
 }
 
+// Upload uploads build outputs of assets, see UploadContext
 func (u Uploader) Upload(assets []Asset) error {
+	return u.UploadContext(context.Background(), assets)
+}
+
+// UploadContext uploads build outputs of assets, canceling ctx aborts
+// running upload
+func (u Uploader) UploadContext(ctx context.Context, assets []Asset) error {
 	p := mpb.New(
 		mpb.WithWidth(60),
 		mpb.WithRefreshRate(100*time.Millisecond),
+		mpb.WithOutput(u.progress),
 	)
 
 	for _, asset := range assets {
@@ -45,6 +56,7 @@ func (u Uploader) Upload(assets []Asset) error {
 
 		reader, size, err := read(asset.BuildPath())
 		if err != nil {
+			p.Wait()
 			return err
 		}
 		bar := p.AddBar(size, mpb.BarStyle("[=>-]"),
@@ -59,7 +71,11 @@ func (u Uploader) Upload(assets []Asset) error {
 			),
 		)
 
-		if err := u.upload(key, bar.ProxyReader(reader)); err != nil {
+		err = u.upload(ctx, key, bar.ProxyReader(reader))
+		reader.Close()
+		if err != nil {
+			p.Abort(bar, false)
+			p.Wait()
 			return err
 		}
 		asset.SetS3Key(key)
@@ -69,20 +85,21 @@ func (u Uploader) Upload(assets []Asset) error {
 	return nil
 }
 
-func read(file string) (io.Reader, int64, error) {
+func read(file string) (io.ReadCloser, int64, error) {
 	f, err := os.Open(file)
 	if err != nil {
 		return nil, 0, err
 	}
 	stat, err := f.Stat()
 	if err != nil {
+		f.Close()
 		return nil, 0, err
 	}
 	return f, stat.Size(), nil
 }
 
-func (u Uploader) upload(key string, reader io.ReadCloser) error {
-	_, err := u.client.Upload(&s3manager.UploadInput{
+func (u Uploader) upload(ctx context.Context, key string, reader io.ReadCloser) error {
+	_, err := u.client.UploadWithContext(ctx, &s3manager.UploadInput{
 		Bucket: aws.String(u.bucket),
 		Key:    aws.String(key),
 		Body:   reader,
@@ -0,0 +1,85 @@
+package gen
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+// Validator validates input of commands and queries in generated main,
+// e.g. by validate struct tags
+type Validator struct {
+	Package string
+	Name    string
+}
+
+var validatorType = reflect.TypeOf((*interface{ Validate() error })(nil)).Elem()
+
+// WithValidator validates input of commands and queries by fn after it is
+// decoded. fn has to be an exported package level function, e.g. wrapping
+// a struct tag validator. Errors are reported as validate.ValidationError,
+// fn can return it to list invalid fields.
+func WithValidator(fn func(interface{}) error) Option {
+	return func(o *options) {
+		o.validator = fn
+	}
+}
+
+// validatorRef returns validated validator referenced by generated main,
+// nil when there is none
+func (o *options) validatorRef() (*Validator, error) {
+	if o.validator == nil {
+		return nil, nil
+	}
+	v := reflect.ValueOf(o.validator)
+	pkg, name, ok := funcName(v)
+	if !ok {
+		return nil, fmt.Errorf("validator %s.%s is not an exported package level function", pkg, name)
+	}
+	return &Validator{Package: pkg, Name: name}, nil
+}
+
+// validation configures validation of method m input, input with validate
+// tags but without validator is reported
+func validation(m *Method, validator *Validator, logger *slog.Logger) {
+	payload := m.Payload()
+	if payload == nil {
+		return
+	}
+	if validator != nil {
+		m.Validator = validator
+	} else if hasValidateTags(payload, map[reflect.Type]bool{}) {
+		logger.Warn("input has validate tags, but no validator is configured, use WithValidator", "input", payload, "method", m.ServiceType.String()+"."+m.Name())
+	}
+}
+
+// Validates reports whether input of method implements Validate() error
+func (m *Method) Validates() bool {
+	payload := m.Payload()
+	if payload == nil {
+		return false
+	}
+	return payload.Implements(validatorType) || reflect.PtrTo(payload).Implements(validatorType)
+}
+
+// hasValidateTags reports whether struct t or its nested structs have
+// fields with validate tags
+func hasValidateTags(t reflect.Type, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return false
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := f.Tag.Lookup("validate"); ok {
+			return true
+		}
+		if hasValidateTags(f.Type, seen) {
+			return true
+		}
+	}
+	return false
+}
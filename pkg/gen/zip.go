@@ -5,20 +5,27 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
-	"log"
 	"os"
 )
 
-func zipFile(filename string, file string) error {
+func zipFile(filename string, file string) (err error) {
 
 	newZipFile, err := os.Create(filename)
 	if err != nil {
 		return err
 	}
-	defer newZipFile.Close()
+	defer func() {
+		if cerr := newZipFile.Close(); err == nil {
+			err = cerr
+		}
+	}()
 
 	zipWriter := zip.NewWriter(newZipFile)
-	defer zipWriter.Close()
+	defer func() {
+		if cerr := zipWriter.Close(); err == nil {
+			err = cerr
+		}
+	}()
 
 	fileToZip, err := os.Open(file)
 	if err != nil {
@@ -46,16 +53,16 @@ func zipFile(filename string, file string) error {
 	return err
 }
 
-func filehash(filename string) string {
+func filehash(filename string) (string, error) {
 	f, err := os.Open(filename)
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 	defer f.Close()
 
 	h := sha256.New()
 	if _, err := io.Copy(h, f); err != nil {
-		log.Fatal(err)
+		return "", err
 	}
-	return fmt.Sprintf("%x", h.Sum(nil))
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
@@ -0,0 +1,133 @@
+// Package lifecycle is used by generated mains to report initialization
+// errors and to clean up services on shutdown.
+package lifecycle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mrzahrada/gen/pkg/logging"
+)
+
+// EnvRuntimeAPI is environment variable with address of Lambda runtime API
+const EnvRuntimeAPI = "AWS_LAMBDA_RUNTIME_API"
+
+// extensionName registers generated main as internal extension
+const extensionName = "gen-lifecycle"
+
+// InitError is failed initialization step of generated main
+type InitError struct {
+	// Step is the failed step, e.g. factory or hook name
+	Step string
+	Err  error
+}
+
+func (e *InitError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Step, e.Err)
+}
+
+func (e *InitError) Unwrap() error {
+	return e.Err
+}
+
+// Fail logs failed initialization step as JSON, reports it to Lambda
+// runtime API and exits
+func Fail(step string, err error) {
+	e := &InitError{Step: step, Err: err}
+	logging.Default().Error("initialization failed", "step", step, "error", err)
+	if api := os.Getenv(EnvRuntimeAPI); api != "" {
+		if err := reportInitError(api, e); err != nil {
+			logging.Default().Error("initialization error not reported", "error", err)
+		}
+	}
+	os.Exit(1)
+}
+
+// OnShutdown calls close when process receives SIGTERM and exits. Lambda
+// sends SIGTERM only to functions with a registered extension, so main
+// registers itself as internal extension when it runs in Lambda.
+func OnShutdown(close func() error) {
+	if api := os.Getenv(EnvRuntimeAPI); api != "" {
+		id, err := register(api)
+		if err != nil {
+			logging.Default().Warn("extension not registered, shutdown is not handled", "error", err)
+			return
+		}
+		go next(api, id)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+	go func() {
+		<-signals
+		if err := close(); err != nil {
+			logging.Default().Error("close failed", "error", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}()
+}
+
+// register registers internal extension without events and returns its
+// identifier
+func register(api string) (string, error) {
+	body := bytes.NewBufferString(`{"events":[]}`)
+	req, err := http.NewRequest(http.MethodPost, "http://"+api+"/2020-01-01/extension/register", body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Lambda-Extension-Name", extensionName)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("register extension: %s", resp.Status)
+	}
+	return resp.Header.Get("Lambda-Extension-Identifier"), nil
+}
+
+// next signals that extension is initialized. Extension registered without
+// events is never woken up, so the request blocks until shutdown.
+func next(api, id string) {
+	req, err := http.NewRequest(http.MethodGet, "http://"+api+"/2020-01-01/extension/event/next", nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Lambda-Extension-Identifier", id)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+func reportInitError(api string, e *InitError) error {
+	body, err := json.Marshal(map[string]string{
+		"errorMessage": e.Error(),
+		"errorType":    "InitError",
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, "http://"+api+"/2018-06-01/runtime/init/error", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Lambda-Runtime-Function-Error-Type", "Runtime.InitError")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("report init error: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Package logging passes log/slog loggers of generated handlers through
+// context. Default logger writes JSON lines into stderr, read by
+// CloudWatch Logs.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// EnvLevel sets level of the default logger, e.g. debug or warn
+const EnvLevel = "LOG_LEVEL"
+
+var defaultLogger = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: envLevel()}))
+
+func envLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(os.Getenv(EnvLevel))); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// Default returns logger writing JSON into stderr at level set by
+// LOG_LEVEL, used by generated mains
+func Default() *slog.Logger {
+	return defaultLogger
+}
+
+// SetDefault replaces default logger
+func SetDefault(l *slog.Logger) {
+	defaultLogger = l
+}
+
+type loggerKey struct{}
+
+// NewContext returns context carrying logger l
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns logger stored by NewContext, default logger when
+// there is none
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return Default()
+}
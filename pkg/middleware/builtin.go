@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/mrzahrada/gen/pkg/logging"
+)
+
+// PanicError is returned by Recover instead of panic of next handler
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Recover converts panic of next handler into PanicError and logs it with
+// stack trace
+func Recover(next Handler) Handler {
+	return func(ctx context.Context, payload json.RawMessage) (result interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				e := &PanicError{Value: r, Stack: debug.Stack()}
+				logging.FromContext(ctx).Error("handler panicked", "error", e, "stack", string(e.Stack))
+				result, err = nil, e
+			}
+		}()
+		return next(ctx, payload)
+	}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns context carrying request ID, e.g. received from
+// the caller. Logger of context logs the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	ctx = logging.NewContext(ctx, logging.FromContext(ctx).With("request_id", id))
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns request ID stored by RequestID, empty when there
+// is none
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID stores request ID in context, so that handler can pass it to
+// downstream calls
+func RequestID(next Handler) Handler {
+	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		return next(EnsureRequestID(ctx), payload)
+	}
+}
+
+// EnsureRequestID returns context carrying request ID. Request ID already
+// in context is kept, Lambda request ID is used otherwise and random ID
+// out of Lambda.
+func EnsureRequestID(ctx context.Context) context.Context {
+	if RequestIDFrom(ctx) != "" {
+		return ctx
+	}
+	return WithRequestID(ctx, newRequestID(ctx))
+}
+
+func newRequestID(ctx context.Context) string {
+	if lc, ok := lambdacontext.FromContext(ctx); ok && lc.AwsRequestID != "" {
+		return lc.AwsRequestID
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Timing logs duration and error of next handler
+func Timing(next Handler) Handler {
+	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		start := time.Now()
+		result, err := next(ctx, payload)
+		duration := float64(time.Since(start)) / float64(time.Millisecond)
+		if err != nil {
+			logging.FromContext(ctx).Error("handled", "duration_ms", duration, "error", err)
+		} else {
+			logging.FromContext(ctx).Info("handled", "duration_ms", duration)
+		}
+		return result, err
+	}
+}
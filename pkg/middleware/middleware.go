@@ -0,0 +1,39 @@
+// Package middleware wraps command and query handlers of generated mains.
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mrzahrada/gen/pkg/logging"
+)
+
+// Handler handles request payload of a command or query
+type Handler func(ctx context.Context, payload json.RawMessage) (interface{}, error)
+
+// Middleware wraps next handler. Middlewares registered by
+// gen.WithMiddleware are exported package level functions, so that
+// generated mains can reference them.
+type Middleware func(next Handler) Handler
+
+type methodKey struct{}
+
+// Chain returns handler of method wrapped by middlewares, the first
+// middleware is the outermost. Middlewares get method name by MethodName,
+// logger of context logs method name and request ID.
+func Chain(method string, h Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		ctx = EnsureRequestID(context.WithValue(ctx, methodKey{}, method))
+		ctx = logging.NewContext(ctx, logging.FromContext(ctx).With("method", method))
+		return h(ctx, payload)
+	}
+}
+
+// MethodName returns name of handled method, empty out of Chain
+func MethodName(ctx context.Context) string {
+	name, _ := ctx.Value(methodKey{}).(string)
+	return name
+}
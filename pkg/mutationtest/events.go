@@ -0,0 +1,139 @@
+package mutationtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	lambdaevents "github.com/aws/aws-lambda-go/events"
+)
+
+// StreamArn is ARN of the stream of Kinesis events
+const StreamArn = "arn:aws:kinesis:us-east-1:123456789012:stream/mutationtest"
+
+// Kinesis returns records as Kinesis event of a single shard of stream
+// StreamArn
+func Kinesis(records ...Record) lambdaevents.KinesisEvent {
+	event := lambdaevents.KinesisEvent{}
+	for _, record := range records {
+		event.Records = append(event.Records, lambdaevents.KinesisEventRecord{
+			EventID:        "shardId-000000000000:" + record.ID,
+			EventSource:    "aws:kinesis",
+			EventSourceArn: StreamArn,
+			Kinesis: lambdaevents.KinesisRecord{
+				Data:                        record.Data,
+				SequenceNumber:              record.ID,
+				ApproximateArrivalTimestamp: lambdaevents.SecondsEpochTime{Time: time.Now()},
+			},
+		})
+	}
+	return event
+}
+
+// DynamoDB returns records as inserted items of DynamoDB stream event
+func DynamoDB(records ...Record) (lambdaevents.DynamoDBEvent, error) {
+	event := lambdaevents.DynamoDBEvent{}
+	for _, record := range records {
+		decoder := json.NewDecoder(bytes.NewReader(record.Data))
+		decoder.UseNumber()
+		var item map[string]interface{}
+		if err := decoder.Decode(&item); err != nil {
+			return event, err
+		}
+		image, err := attribute(item)
+		if err != nil {
+			return event, err
+		}
+		event.Records = append(event.Records, lambdaevents.DynamoDBEventRecord{
+			EventID:     record.ID,
+			EventName:   "INSERT",
+			EventSource: "aws:dynamodb",
+			Change: lambdaevents.DynamoDBStreamRecord{
+				SequenceNumber:              record.ID,
+				NewImage:                    image.Map(),
+				ApproximateCreationDateTime: lambdaevents.SecondsEpochTime{Time: time.Now()},
+			},
+		})
+	}
+	return event, nil
+}
+
+// SQS returns records as SQS messages
+func SQS(records ...Record) lambdaevents.SQSEvent {
+	event := lambdaevents.SQSEvent{}
+	for _, record := range records {
+		event.Records = append(event.Records, lambdaevents.SQSMessage{
+			MessageId: record.ID,
+			Body:      string(record.Data),
+			Attributes: map[string]string{
+				"SentTimestamp": fmt.Sprint(time.Now().UnixNano() / int64(time.Millisecond)),
+			},
+		})
+	}
+	return event
+}
+
+// SNS returns records as SNS notifications
+func SNS(records ...Record) lambdaevents.SNSEvent {
+	event := lambdaevents.SNSEvent{}
+	for _, record := range records {
+		event.Records = append(event.Records, lambdaevents.SNSEventRecord{
+			EventSource: "aws:sns",
+			SNS: lambdaevents.SNSEntity{
+				MessageID: record.ID,
+				Message:   string(record.Data),
+				Timestamp: time.Now(),
+			},
+		})
+	}
+	return event
+}
+
+// EventBridge returns record as EventBridge event with event type as
+// detail type
+func EventBridge(record Record) lambdaevents.CloudWatchEvent {
+	return lambdaevents.CloudWatchEvent{
+		ID:         record.ID,
+		DetailType: record.Type,
+		Source:     "mutationtest",
+		Time:       time.Now(),
+		Detail:     record.Data,
+	}
+}
+
+// attribute converts JSON value decoded with json.Number into DynamoDB
+// attribute
+func attribute(value interface{}) (lambdaevents.DynamoDBAttributeValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return lambdaevents.NewNullAttribute(), nil
+	case string:
+		return lambdaevents.NewStringAttribute(v), nil
+	case json.Number:
+		return lambdaevents.NewNumberAttribute(v.String()), nil
+	case bool:
+		return lambdaevents.NewBooleanAttribute(v), nil
+	case []interface{}:
+		list := []lambdaevents.DynamoDBAttributeValue{}
+		for _, item := range v {
+			a, err := attribute(item)
+			if err != nil {
+				return a, err
+			}
+			list = append(list, a)
+		}
+		return lambdaevents.NewListAttribute(list), nil
+	case map[string]interface{}:
+		m := map[string]lambdaevents.DynamoDBAttributeValue{}
+		for key, item := range v {
+			a, err := attribute(item)
+			if err != nil {
+				return a, err
+			}
+			m[key] = a
+		}
+		return lambdaevents.NewMapAttribute(m), nil
+	}
+	return lambdaevents.DynamoDBAttributeValue{}, fmt.Errorf("unsupported value %T", value)
+}
@@ -0,0 +1,97 @@
+// Package mutationtest supports tests of mutations generated by
+// Service.WriteMutationTest. Generated Run marshals events with Marshal,
+// wraps them into Lambda event of the mutation source and passes them
+// through the same handler which is deployed.
+package mutationtest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/mrzahrada/gen/pkg/deadletter"
+)
+
+// Record is a marshaled event, ID is its position counted from 1
+type Record struct {
+	ID   string
+	Type string
+	Data []byte
+}
+
+// Result of events passed through the generated handler
+type Result struct {
+	// Failed is index of the first failed event, -1 when none failed
+	Failed int
+	// Retried are indexes of events reported as batch item failures
+	Retried []int
+	// Pushes is number of Push calls
+	Pushes int
+	// Quarantined are letters written into the dead letter sink
+	Quarantined []deadletter.Letter
+}
+
+// Event is event data with explicit event type name, e.g. one of several
+// names mapped to the same handler
+type Event struct {
+	Name string
+	Data interface{}
+}
+
+// Marshal marshals events the way es publisher does, as JSON object with
+// event type name t and event data d. Type name of the event is used
+// unless it is passed as Event.
+func Marshal(events ...interface{}) ([]Record, error) {
+	return MarshalNamed(nil, events...)
+}
+
+// MarshalNamed marshals events like Marshal, name returns event type name
+// registered for event, empty when it is not registered
+func MarshalNamed(name func(event interface{}) string, events ...interface{}) ([]Record, error) {
+	result := []Record{}
+	for i, event := range events {
+		typeName := ""
+		if e, ok := event.(Event); ok {
+			typeName, event = e.Name, e.Data
+		}
+		if typeName == "" && name != nil {
+			typeName = name(event)
+		}
+		if typeName == "" {
+			t := reflect.TypeOf(event)
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			typeName = t.Name()
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		data, err = json.Marshal(struct {
+			Type string          `json:"t"`
+			Data json.RawMessage `json:"d"`
+		}{typeName, data})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, Record{
+			ID:   strconv.Itoa(i + 1),
+			Type: typeName,
+			Data: data,
+		})
+	}
+	return result, nil
+}
+
+// AssertPushed fails t unless all events succeeded and Push was called
+func (r Result) AssertPushed(t testing.TB) {
+	t.Helper()
+	if r.Failed >= 0 {
+		t.Errorf("event %d failed", r.Failed)
+	}
+	if r.Pushes == 0 {
+		t.Error("push was not called")
+	}
+}
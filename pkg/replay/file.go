@@ -0,0 +1,12 @@
+package replay
+
+import "os"
+
+// File returns reader of local JSON lines file
+func File(name string) (Reader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return newLines(f), nil
+}
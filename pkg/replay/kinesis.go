@@ -0,0 +1,124 @@
+package replay
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+)
+
+// pollInterval keeps GetRecords under the limit of 5 calls per shard
+const pollInterval = 200 * time.Millisecond
+
+// Kinesis reads stream shard by shard from TRIM_HORIZON until the shard is
+// closed or its end is reached
+type Kinesis struct {
+	client kinesisiface.KinesisAPI
+	stream string
+
+	shards   []string
+	listed   bool
+	iterator *string
+	records  []*kinesis.Record
+}
+
+// NewKinesis -
+func NewKinesis(client kinesisiface.KinesisAPI, stream string) *Kinesis {
+	return &Kinesis{
+		client: client,
+		stream: stream,
+	}
+}
+
+// Read - implements Reader.Read
+func (r *Kinesis) Read(ctx context.Context) ([]byte, error) {
+	if !r.listed {
+		if err := r.list(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for len(r.records) == 0 {
+		if r.iterator == nil {
+			if len(r.shards) == 0 {
+				return nil, io.EOF
+			}
+			if err := r.open(ctx, r.shards[0]); err != nil {
+				return nil, err
+			}
+			r.shards = r.shards[1:]
+		}
+		if err := r.next(ctx); err != nil {
+			return nil, err
+		}
+	}
+	record := r.records[0]
+	r.records = r.records[1:]
+	return record.Data, nil
+}
+
+// Close - implements Reader.Close
+func (r *Kinesis) Close() error {
+	return nil
+}
+
+// list returns shards in order of creation, parents precede children
+func (r *Kinesis) list(ctx context.Context) error {
+	input := &kinesis.ListShardsInput{
+		StreamName: aws.String(r.stream),
+	}
+	for {
+		output, err := r.client.ListShardsWithContext(ctx, input)
+		if err != nil {
+			return err
+		}
+		for _, shard := range output.Shards {
+			r.shards = append(r.shards, aws.StringValue(shard.ShardId))
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input = &kinesis.ListShardsInput{
+			NextToken: output.NextToken,
+		}
+	}
+	r.listed = true
+	return nil
+}
+
+func (r *Kinesis) open(ctx context.Context, shard string) error {
+	output, err := r.client.GetShardIteratorWithContext(ctx, &kinesis.GetShardIteratorInput{
+		StreamName:        aws.String(r.stream),
+		ShardId:           aws.String(shard),
+		ShardIteratorType: aws.String(kinesis.ShardIteratorTypeTrimHorizon),
+	})
+	if err != nil {
+		return err
+	}
+	r.iterator = output.ShardIterator
+	return nil
+}
+
+// next reads records of current shard, iterator is cleared at the end of
+// the shard
+func (r *Kinesis) next(ctx context.Context) error {
+	output, err := r.client.GetRecordsWithContext(ctx, &kinesis.GetRecordsInput{
+		ShardIterator: r.iterator,
+	})
+	if err != nil {
+		return err
+	}
+	r.records = output.Records
+	r.iterator = output.NextShardIterator
+	if len(output.Records) == 0 && aws.Int64Value(output.MillisBehindLatest) == 0 {
+		r.iterator = nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(pollInterval):
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Reader reads archived events in order
+type Reader interface {
+	// Read returns data of the next event, io.EOF after the last one
+	Read(ctx context.Context) ([]byte, error)
+	Close() error
+}
+
+// Open returns reader of uri. Supported are s3://bucket/prefix with JSON
+// lines objects, kinesis://stream read from TRIM_HORIZON and local JSON
+// lines file.
+func Open(uri string) (Reader, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || u.Scheme == "file" {
+		return File(strings.TrimPrefix(uri, "file://"))
+	}
+	switch u.Scheme {
+	case "s3":
+		sess, err := session.NewSession()
+		if err != nil {
+			return nil, err
+		}
+		return NewS3(s3.New(sess), u.Host, strings.TrimPrefix(u.Path, "/")), nil
+	case "kinesis":
+		sess, err := session.NewSession()
+		if err != nil {
+			return nil, err
+		}
+		return NewKinesis(kinesis.New(sess), u.Host), nil
+	}
+	return nil, fmt.Errorf("replay: unknown source %q", uri)
+}
+
+// lines reads JSON lines, empty lines are skipped
+type lines struct {
+	reader *bufio.Reader
+	closer io.Closer
+}
+
+func newLines(r io.ReadCloser) *lines {
+	return &lines{
+		reader: bufio.NewReader(r),
+		closer: r,
+	}
+}
+
+func (l *lines) Read(ctx context.Context) ([]byte, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		line, err := l.reader.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (l *lines) Close() error {
+	return l.closer.Close()
+}
@@ -0,0 +1,109 @@
+package replay
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// S3 reads JSON lines objects under prefix in order of their keys.
+// Objects with .gz suffix are decompressed.
+type S3 struct {
+	client s3iface.S3API
+	bucket string
+	prefix string
+
+	keys    []string
+	listed  bool
+	current *lines
+}
+
+// NewS3 -
+func NewS3(client s3iface.S3API, bucket, prefix string) *S3 {
+	return &S3{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+// Read - implements Reader.Read
+func (r *S3) Read(ctx context.Context) ([]byte, error) {
+	if !r.listed {
+		if err := r.list(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		if r.current != nil {
+			data, err := r.current.Read(ctx)
+			if err != io.EOF {
+				return data, err
+			}
+			r.current.Close()
+			r.current = nil
+		}
+		if len(r.keys) == 0 {
+			return nil, io.EOF
+		}
+		if err := r.open(ctx, r.keys[0]); err != nil {
+			return nil, err
+		}
+		r.keys = r.keys[1:]
+	}
+}
+
+// Close - implements Reader.Close
+func (r *S3) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	return r.current.Close()
+}
+
+func (r *S3) list(ctx context.Context) error {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(r.bucket),
+		Prefix: aws.String(r.prefix),
+	}
+	err := r.client.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, last bool) bool {
+		for _, object := range page.Contents {
+			r.keys = append(r.keys, aws.StringValue(object.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	r.listed = true
+	return nil
+}
+
+func (r *S3) open(ctx context.Context, key string) error {
+	output, err := r.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(r.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return err
+	}
+	body := output.Body
+	if strings.HasSuffix(key, ".gz") {
+		unzipped, err := gzip.NewReader(body)
+		if err != nil {
+			body.Close()
+			return err
+		}
+		body = struct {
+			io.Reader
+			io.Closer
+		}{unzipped, body}
+	}
+	r.current = newLines(body)
+	return nil
+}
@@ -0,0 +1,235 @@
+package source
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	lambdaevents "github.com/aws/aws-lambda-go/events"
+)
+
+// Record is an event record independent of its source. Data is decoded by
+// es.Unmarshaler, ID is reported as batch item failure. Stream and Shard
+// are set for Kinesis only, PartitionKey for Kinesis and SQS FIFO.
+type Record struct {
+	ID string
+	// Stream is ARN of the stream the record was read from
+	Stream       string
+	Shard        string
+	PartitionKey string
+	Data         []byte
+	Arrived      time.Time
+}
+
+// Envelope is metadata of an event passed to handlers with signature
+// On<Event>(context.Context, source.Envelope, *Event) error
+type Envelope struct {
+	// Type is es event type
+	Type string
+	// EventID, Version, At, User and Organization are read from es.Model
+	// embedded in the event, they are empty otherwise
+	EventID      string
+	Version      int
+	At           time.Time
+	User         string
+	Organization string
+	// PartitionKey is the aggregate ID for events published by es
+	PartitionKey string
+	Shard        string
+	// Sequence is ID of the record, sequence number for streams
+	Sequence string
+	Arrived  time.Time
+}
+
+// Kinesis returns records of Kinesis event
+func Kinesis(input lambdaevents.KinesisEvent) ([]Record, error) {
+	result := []Record{}
+	for _, record := range input.Records {
+		// event ID has format shardId-000000000000:sequence
+		shard := record.EventID
+		if i := strings.Index(shard, ":"); i >= 0 {
+			shard = shard[:i]
+		}
+		result = append(result, Record{
+			ID:           record.Kinesis.SequenceNumber,
+			Stream:       record.EventSourceArn,
+			Shard:        shard,
+			PartitionKey: record.Kinesis.PartitionKey,
+			Data:         record.Kinesis.Data,
+			Arrived:      record.Kinesis.ApproximateArrivalTimestamp.Time,
+		})
+	}
+	return result, nil
+}
+
+// DynamoDB returns new images of DynamoDB stream event as JSON objects.
+// Removed items are skipped.
+func DynamoDB(input lambdaevents.DynamoDBEvent) ([]Record, error) {
+	result := []Record{}
+	for _, record := range input.Records {
+		if len(record.Change.NewImage) == 0 {
+			continue
+		}
+		data, err := json.Marshal(image(record.Change.NewImage))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, Record{
+			ID:      record.Change.SequenceNumber,
+			Data:    data,
+			Arrived: record.Change.ApproximateCreationDateTime.Time,
+		})
+	}
+	return result, nil
+}
+
+// SQS returns bodies of SQS messages
+func SQS(input lambdaevents.SQSEvent) ([]Record, error) {
+	result := []Record{}
+	for _, message := range input.Records {
+		var arrived time.Time
+		if ms, err := strconv.ParseInt(message.Attributes["SentTimestamp"], 10, 64); err == nil {
+			arrived = time.Unix(0, ms*int64(time.Millisecond))
+		}
+		result = append(result, Record{
+			ID:           message.MessageId,
+			PartitionKey: message.Attributes["MessageGroupId"],
+			Data:         []byte(message.Body),
+			Arrived:      arrived,
+		})
+	}
+	return result, nil
+}
+
+// SNS returns SNS messages
+func SNS(input lambdaevents.SNSEvent) ([]Record, error) {
+	result := []Record{}
+	for _, record := range input.Records {
+		result = append(result, Record{
+			ID:      record.SNS.MessageID,
+			Data:    []byte(record.SNS.Message),
+			Arrived: record.SNS.Timestamp,
+		})
+	}
+	return result, nil
+}
+
+// EventBridge returns detail of EventBridge event. Detail which is not an
+// es event is wrapped using detail-type as event type.
+func EventBridge(input lambdaevents.CloudWatchEvent) ([]Record, error) {
+	record := Record{
+		ID:      input.ID,
+		Data:    []byte(input.Detail),
+		Arrived: input.Time,
+	}
+
+	if record.EventType() == "" {
+		wrapped, err := json.Marshal(struct {
+			Type string          `json:"t"`
+			Data json.RawMessage `json:"d"`
+		}{input.DetailType, input.Detail})
+		if err != nil {
+			return nil, err
+		}
+		record.Data = wrapped
+	}
+
+	return []Record{record}, nil
+}
+
+// image converts DynamoDB item into plain JSON value
+func image(item map[string]lambdaevents.DynamoDBAttributeValue) map[string]interface{} {
+	result := map[string]interface{}{}
+	for key, value := range item {
+		result[key] = attribute(value)
+	}
+	return result
+}
+
+func attribute(value lambdaevents.DynamoDBAttributeValue) interface{} {
+	switch value.DataType() {
+	case lambdaevents.DataTypeString:
+		return value.String()
+	case lambdaevents.DataTypeNumber:
+		return json.Number(value.Number())
+	case lambdaevents.DataTypeBoolean:
+		return value.Boolean()
+	case lambdaevents.DataTypeBinary:
+		return base64.StdEncoding.EncodeToString(value.Binary())
+	case lambdaevents.DataTypeMap:
+		return image(value.Map())
+	case lambdaevents.DataTypeList:
+		result := []interface{}{}
+		for _, v := range value.List() {
+			result = append(result, attribute(v))
+		}
+		return result
+	case lambdaevents.DataTypeStringSet:
+		return value.StringSet()
+	case lambdaevents.DataTypeNumberSet:
+		result := []json.Number{}
+		for _, n := range value.NumberSet() {
+			result = append(result, json.Number(n))
+		}
+		return result
+	case lambdaevents.DataTypeBinarySet:
+		result := []string{}
+		for _, b := range value.BinarySet() {
+			result = append(result, base64.StdEncoding.EncodeToString(b))
+		}
+		return result
+	}
+	return nil
+}
+
+// IDs returns IDs of records
+func IDs(records []Record) []string {
+	result := []string{}
+	for _, record := range records {
+		result = append(result, record.ID)
+	}
+	return result
+}
+
+// EventType returns es event type of record data, empty when data is not
+// an es event
+func (r Record) EventType() string {
+	envelope := struct {
+		Type string `json:"t"`
+	}{}
+	if err := json.Unmarshal(r.Data, &envelope); err != nil {
+		return ""
+	}
+	return envelope.Type
+}
+
+// Envelope returns metadata of record and its event
+func (r Record) Envelope() Envelope {
+	event := struct {
+		Type string `json:"t"`
+		Data struct {
+			EventID      string    `json:"_id"`
+			Version      int       `json:"_v"`
+			At           time.Time `json:"_at"`
+			User         string    `json:"_u"`
+			Organization string    `json:"_org"`
+		} `json:"d"`
+	}{}
+	// metadata is optional, event data is validated by es.Unmarshaler
+	json.Unmarshal(r.Data, &event)
+
+	return Envelope{
+		Type:         event.Type,
+		EventID:      event.Data.EventID,
+		Version:      event.Data.Version,
+		At:           event.Data.At,
+		User:         event.Data.User,
+		Organization: event.Data.Organization,
+		PartitionKey: r.PartitionKey,
+		Shard:        r.Shard,
+		Sequence:     r.ID,
+		Arrived:      r.Arrived,
+	}
+}
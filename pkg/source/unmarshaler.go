@@ -0,0 +1,53 @@
+package source
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/mrzahrada/es"
+)
+
+// Unmarshaler decodes es events like es.Unmarshaler. Event type names are
+// registered explicitly, so that events with the same type name from
+// different packages or renamed events can be mapped.
+type Unmarshaler struct {
+	types map[string]reflect.Type
+}
+
+// NewUnmarshaler -
+func NewUnmarshaler() *Unmarshaler {
+	return &Unmarshaler{
+		types: map[string]reflect.Type{},
+	}
+}
+
+// Register maps event type name to type of event
+func (u *Unmarshaler) Register(name string, event interface{}) *Unmarshaler {
+	t := reflect.TypeOf(event)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	u.types[name] = t
+	return u
+}
+
+// Unmarshal returns pointer to event of data, errors are the same as of
+// es.Unmarshaler
+func (u *Unmarshaler) Unmarshal(data []byte) (interface{}, error) {
+	envelope := struct {
+		Type string          `json:"t"`
+		Data json.RawMessage `json:"d"`
+	}{}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, es.ErrUnmarshalEvent
+	}
+	t, ok := u.types[envelope.Type]
+	if !ok {
+		return nil, es.ErrUnknownEventType
+	}
+	v := reflect.New(t).Interface()
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		return nil, es.ErrUnmarshalEvent
+	}
+	return v, nil
+}
@@ -0,0 +1,164 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// maxValues is the limit of values of a metric in EMF entry
+const maxValues = 100
+
+// EMF is metric exporter writing CloudWatch embedded metric format, e.g.
+// into stdout read by CloudWatch Logs. Values are exported per flush, so
+// that metrics of an invocation are written together.
+type EMF struct {
+	mu        sync.Mutex
+	w         io.Writer
+	namespace string
+}
+
+// NewEMF returns exporter writing into w, metrics are put into namespace
+// with dimension Operation
+func NewEMF(w io.Writer, namespace string) *EMF {
+	return &EMF{w: w, namespace: namespace}
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// Temporality - implements metric.Exporter, values are reported once
+func (e *EMF) Temporality(sdkmetric.InstrumentKind) metricdata.Temporality {
+	return metricdata.DeltaTemporality
+}
+
+// Aggregation - implements metric.Exporter
+func (e *EMF) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+// Export writes an entry per operation. Histogram is written as its mean
+// repeated per recorded value, so that CloudWatch sum, average and sample
+// count match.
+func (e *EMF) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entries := map[string]map[string]interface{}{}
+	definitions := map[string][]emfMetric{}
+	add := func(operation, name, unit string, values []float64) {
+		if len(values) == 0 {
+			return
+		}
+		entry, ok := entries[operation]
+		if !ok {
+			entry = map[string]interface{}{"Operation": operation}
+			entries[operation] = entry
+		}
+		entry[name] = values
+		definitions[operation] = append(definitions[operation], emfMetric{Name: name, Unit: emfUnit(unit)})
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					operation, _ := point.Attributes.Value("operation")
+					values := []float64{}
+					for i := uint64(0); i < point.Count && i < maxValues; i++ {
+						values = append(values, point.Sum/float64(point.Count))
+					}
+					add(operation.AsString(), m.Name, m.Unit, values)
+				}
+			case metricdata.Sum[float64]:
+				for _, point := range data.DataPoints {
+					operation, _ := point.Attributes.Value("operation")
+					add(operation.AsString(), m.Name, m.Unit, []float64{point.Value})
+				}
+			}
+		}
+	}
+
+	operations := []string{}
+	for operation := range entries {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	for _, operation := range operations {
+		entry := entries[operation]
+		entry["_aws"] = map[string]interface{}{
+			"Timestamp": timestamp(rm),
+			"CloudWatchMetrics": []interface{}{map[string]interface{}{
+				"Namespace":  e.namespace,
+				"Dimensions": [][]string{{"Operation"}},
+				"Metrics":    definitions[operation],
+			}},
+		}
+		if err := e.write(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForceFlush - implements metric.Exporter, entries are written by Export
+func (e *EMF) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+// Shutdown - implements metric.Exporter
+func (e *EMF) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (e *EMF) write(entry map[string]interface{}) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(e.w, string(b))
+	return err
+}
+
+// emfUnit returns CloudWatch unit of OpenTelemetry unit
+func emfUnit(unit string) string {
+	switch unit {
+	case Milliseconds:
+		return "Milliseconds"
+	case "s":
+		return "Seconds"
+	}
+	return "Count"
+}
+
+// timestamp returns time of the newest data point in milliseconds
+func timestamp(rm *metricdata.ResourceMetrics) int64 {
+	var ms int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					if t := point.Time.UnixNano() / 1e6; t > ms {
+						ms = t
+					}
+				}
+			case metricdata.Sum[float64]:
+				for _, point := range data.DataPoints {
+					if t := point.Time.UnixNano() / 1e6; t > ms {
+						ms = t
+					}
+				}
+			}
+		}
+	}
+	return ms
+}
@@ -0,0 +1,259 @@
+// Package telemetry records spans and metrics of generated handlers with
+// OpenTelemetry SDK. Spans and metrics of an invocation are flushed when
+// its root span finishes, before Lambda freezes the process.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mrzahrada/gen/pkg/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// EnvExporter selects exporter used by FromEnv, emf or otlp
+	EnvExporter = "TELEMETRY_EXPORTER"
+	// EnvNamespace is CloudWatch namespace of EMF metrics, function name
+	// is used by default
+	EnvNamespace = "TELEMETRY_NAMESPACE"
+	// EnvEndpoint is base URL of OTLP HTTP collector, read by OTLP
+	// exporters
+	EnvEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+)
+
+// instrumentation is name of tracer and meter of generated handlers
+const instrumentation = "github.com/mrzahrada/gen/pkg/telemetry"
+
+// Units of metrics
+const (
+	Milliseconds = "ms"
+	Count        = "1"
+)
+
+// Provider records spans and metrics and exports them by SDK exporters
+type Provider struct {
+	traces  *sdktrace.TracerProvider
+	metrics *sdkmetric.MeterProvider
+	tracer  trace.Tracer
+	meter   metric.Meter
+
+	mu         sync.Mutex
+	histograms map[string]metric.Float64Histogram
+	counters   map[string]metric.Float64Counter
+}
+
+// NewProvider returns provider exporting spans by exporter and metrics
+// collected by reader, e.g. tracetest.InMemoryExporter and
+// metric.ManualReader in tests
+func NewProvider(exporter sdktrace.SpanExporter, reader sdkmetric.Reader) *Provider {
+	res := serviceResource()
+	p := &Provider{
+		traces: sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+		),
+		metrics: sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithResource(res),
+		),
+		histograms: map[string]metric.Float64Histogram{},
+		counters:   map[string]metric.Float64Counter{},
+	}
+	p.tracer = p.traces.Tracer(instrumentation)
+	p.meter = p.metrics.Meter(instrumentation)
+	return p
+}
+
+// serviceResource describes the function, service name is taken from
+// OTEL_SERVICE_NAME or Lambda function name
+func serviceResource() *resource.Resource {
+	name := os.Getenv("OTEL_SERVICE_NAME")
+	if name == "" {
+		name = os.Getenv("AWS_LAMBDA_FUNCTION_NAME")
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", name)))
+	if err != nil {
+		return resource.Default()
+	}
+	return res
+}
+
+// FromEnv returns provider with exporters selected by TELEMETRY_EXPORTER,
+// nil when it is not set:
+//
+//	emf   spans as JSON lines and metrics in CloudWatch embedded metric
+//	      format written into stdout
+//	otlp  spans and metrics sent to OTLP HTTP collector configured by
+//	      OTEL_EXPORTER_OTLP_* variables
+func FromEnv() (*Provider, error) {
+	ctx := context.Background()
+	switch kind := os.Getenv(EnvExporter); kind {
+	case "":
+		return nil, nil
+	case "emf":
+		namespace := os.Getenv(EnvNamespace)
+		if namespace == "" {
+			namespace = os.Getenv("AWS_LAMBDA_FUNCTION_NAME")
+		}
+		spans, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, err
+		}
+		return NewProvider(spans, sdkmetric.NewPeriodicReader(NewEMF(os.Stdout, namespace))), nil
+	case "otlp":
+		spans, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		metrics, err := otlpmetrichttp.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return NewProvider(spans, sdkmetric.NewPeriodicReader(metrics)), nil
+	default:
+		return nil, fmt.Errorf("telemetry: unknown exporter %q", kind)
+	}
+}
+
+var provider *Provider
+
+// SetProvider sets provider of all spans and metrics and registers it as
+// global OpenTelemetry provider, so that spans of services are children of
+// handler spans. Spans are not recorded without provider.
+func SetProvider(p *Provider) {
+	provider = p
+	if p != nil {
+		otel.SetTracerProvider(p.traces)
+		otel.SetMeterProvider(p.metrics)
+	}
+}
+
+// Flush exports recorded spans and metrics
+func (p *Provider) Flush(ctx context.Context) error {
+	return errors.Join(p.traces.ForceFlush(ctx), p.metrics.ForceFlush(ctx))
+}
+
+// Shutdown flushes and stops exporters
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return errors.Join(p.traces.Shutdown(ctx), p.metrics.Shutdown(ctx))
+}
+
+// record records value of histogram name with operation attribute
+func (p *Provider) record(ctx context.Context, operation, name, unit string, value float64) {
+	p.mu.Lock()
+	histogram, ok := p.histograms[name]
+	if !ok {
+		histogram, _ = p.meter.Float64Histogram(name, metric.WithUnit(unit))
+		p.histograms[name] = histogram
+	}
+	p.mu.Unlock()
+	histogram.Record(ctx, value, metric.WithAttributes(attribute.String("operation", operation)))
+}
+
+// add adds value to counter name with operation attribute
+func (p *Provider) add(ctx context.Context, operation, name string, value float64) {
+	p.mu.Lock()
+	counter, ok := p.counters[name]
+	if !ok {
+		counter, _ = p.meter.Float64Counter(name, metric.WithUnit(Count))
+		p.counters[name] = counter
+	}
+	p.mu.Unlock()
+	counter.Add(ctx, value, metric.WithAttributes(attribute.String("operation", operation)))
+}
+
+// String returns string attribute
+func String(key, value string) attribute.KeyValue {
+	return attribute.String(key, value)
+}
+
+// Int returns integer attribute
+func Int(key string, value int) attribute.KeyValue {
+	return attribute.Int(key, value)
+}
+
+// Span is a timed operation of a trace
+type Span struct {
+	Name  string
+	Start time.Time
+
+	span     trace.Span
+	provider *Provider
+	root     bool
+}
+
+type spanKey struct{}
+
+// Start starts span name as a child of span in ctx, span is not recorded
+// without provider
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, *Span) {
+	span := &Span{Name: name, Start: time.Now()}
+	p := provider
+	if p == nil {
+		return ctx, span
+	}
+	span.provider = p
+	span.root = !trace.SpanContextFromContext(ctx).IsValid()
+	ctx, span.span = p.tracer.Start(ctx, name, trace.WithAttributes(attrs...), trace.WithTimestamp(span.Start))
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns span started by Start, nil when there is none
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SetAttributes adds attributes of span
+func (s *Span) SetAttributes(attrs ...attribute.KeyValue) {
+	if s.span != nil {
+		s.span.SetAttributes(attrs...)
+	}
+}
+
+// Finish ends span with err and records its duration and errors. Spans
+// and metrics are flushed once the root span is finished, flush errors
+// are logged.
+func (s *Span) Finish(ctx context.Context, err error) {
+	if s.span == nil {
+		return
+	}
+	end := time.Now()
+	failed := 0.0
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+		failed = 1
+	}
+	s.span.End(trace.WithTimestamp(end))
+	s.provider.record(ctx, s.Name, "Duration", Milliseconds, float64(end.Sub(s.Start))/float64(time.Millisecond))
+	s.provider.add(ctx, s.Name, "Errors", failed)
+	if !s.root {
+		return
+	}
+	if err := s.provider.Flush(ctx); err != nil {
+		logging.FromContext(ctx).Error("telemetry export failed", "error", err)
+	}
+}
+
+// Record records metric within span of ctx, it is dropped out of span
+func Record(ctx context.Context, name, unit string, value float64) {
+	if span := FromContext(ctx); span != nil && span.provider != nil {
+		span.provider.record(ctx, span.Name, name, unit, value)
+	}
+}
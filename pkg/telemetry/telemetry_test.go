@@ -0,0 +1,177 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mrzahrada/gen/pkg/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setup sets provider exporting into memory until the end of test
+func setup(t *testing.T) (*tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	t.Helper()
+	spans := tracetest.NewInMemoryExporter()
+	metrics := sdkmetric.NewManualReader()
+	SetProvider(NewProvider(spans, metrics))
+	t.Cleanup(func() { SetProvider(nil) })
+	return spans, metrics
+}
+
+// collect returns data points of metrics by metric name and operation
+func collect(t *testing.T, reader *sdkmetric.ManualReader) map[string]map[string]float64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	result := map[string]map[string]float64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			result[m.Name] = map[string]float64{}
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				for _, point := range data.DataPoints {
+					operation, _ := point.Attributes.Value("operation")
+					result[m.Name][operation.AsString()] = float64(point.Count)
+				}
+			case metricdata.Sum[float64]:
+				for _, point := range data.DataPoints {
+					operation, _ := point.Attributes.Value("operation")
+					result[m.Name][operation.AsString()] = point.Value
+				}
+			}
+		}
+	}
+	return result
+}
+
+func TestSpans(t *testing.T) {
+	spans, metrics := setup(t)
+
+	ctx, batch := Start(context.Background(), "Projector", String("source", "kinesis"))
+	Record(ctx, "BatchSize", Count, 2)
+	for _, err := range []error{nil, errors.New("failed")} {
+		_, event := Start(ctx, "Created", String("record.id", "1"))
+		event.Finish(ctx, err)
+	}
+	if len(spans.GetSpans()) != 0 {
+		t.Error("spans exported before the root span finished")
+	}
+	batch.SetAttributes(Int("batch.size", 2))
+	batch.Finish(ctx, nil)
+
+	exported := spans.GetSpans()
+	if len(exported) != 3 {
+		t.Fatalf("exported %d spans, want 3", len(exported))
+	}
+	root := exported[2]
+	if root.Name != "Projector" || root.Parent.IsValid() {
+		t.Errorf("root span %s has parent %v", root.Name, root.Parent)
+	}
+	if !hasAttribute(root.Attributes, attribute.Int("batch.size", 2)) {
+		t.Errorf("root span attributes %v", root.Attributes)
+	}
+	for _, span := range exported[:2] {
+		if span.Parent.SpanID() != root.SpanContext.SpanID() || span.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("span %s is not a child of root span", span.Name)
+		}
+	}
+	if exported[0].Status.Code != codes.Unset || exported[1].Status.Code != codes.Error {
+		t.Errorf("span statuses %v, %v", exported[0].Status, exported[1].Status)
+	}
+
+	got := collect(t, metrics)
+	if got["Duration"]["Created"] != 2 || got["Duration"]["Projector"] != 1 {
+		t.Errorf("durations %v", got["Duration"])
+	}
+	if got["Errors"]["Created"] != 1 || got["Errors"]["Projector"] != 0 {
+		t.Errorf("errors %v", got["Errors"])
+	}
+	if got["BatchSize"]["Projector"] != 1 {
+		t.Errorf("batch sizes %v", got["BatchSize"])
+	}
+}
+
+func TestWithoutProvider(t *testing.T) {
+	ctx, span := Start(context.Background(), "Create")
+	Record(ctx, "BatchSize", Count, 1)
+	span.SetAttributes(String("key", "value"))
+	span.Finish(ctx, errors.New("failed"))
+	if FromContext(ctx) != nil {
+		t.Error("span recorded without provider")
+	}
+}
+
+func TestTrace(t *testing.T) {
+	spans, metrics := setup(t)
+
+	handler := middleware.Chain("Create", func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		if FromContext(ctx) == nil {
+			t.Error("handler is called without span")
+		}
+		return nil, nil
+	}, Trace)
+	if _, err := handler(context.Background(), json.RawMessage(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+	exported := spans.GetSpans()
+	if len(exported) != 1 || exported[0].Name != "Create" {
+		t.Fatalf("exported spans %v", exported)
+	}
+	if got := collect(t, metrics); got["Duration"]["Create"] != 1 {
+		t.Errorf("durations %v", got["Duration"])
+	}
+}
+
+func TestEMF(t *testing.T) {
+	var b bytes.Buffer
+	SetProvider(NewProvider(tracetest.NewInMemoryExporter(), sdkmetric.NewPeriodicReader(NewEMF(&b, "shop"))))
+	t.Cleanup(func() { SetProvider(nil) })
+
+	ctx, span := Start(context.Background(), "Create")
+	span.Finish(ctx, nil)
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("written %d entries, want 1:\n%s", len(lines), b.String())
+	}
+	entry := struct {
+		AWS struct {
+			CloudWatchMetrics []struct {
+				Namespace  string
+				Dimensions [][]string
+				Metrics    []emfMetric
+			}
+		} `json:"_aws"`
+		Operation string
+		Duration  []float64
+		Errors    []float64
+	}{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Operation != "Create" || len(entry.Duration) != 1 || len(entry.Errors) != 1 || entry.Errors[0] != 0 {
+		t.Errorf("entry %s", lines[0])
+	}
+	if len(entry.AWS.CloudWatchMetrics) != 1 || entry.AWS.CloudWatchMetrics[0].Namespace != "shop" {
+		t.Errorf("metric directive %s", lines[0])
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
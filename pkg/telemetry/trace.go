@@ -0,0 +1,20 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mrzahrada/gen/pkg/middleware"
+)
+
+// Trace is middleware starting span per invocation named by method
+func Trace(next middleware.Handler) middleware.Handler {
+	return func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		ctx, span := Start(ctx, middleware.MethodName(ctx),
+			String("request.id", middleware.RequestIDFrom(ctx)),
+		)
+		result, err := next(ctx, payload)
+		span.Finish(ctx, err)
+		return result, err
+	}
+}
@@ -0,0 +1,50 @@
+// Package validate reports invalid input of commands and queries.
+package validate
+
+import (
+	"errors"
+	"strings"
+)
+
+// FieldError is invalid field of input
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned by generated mains for invalid input and
+// reported to callers as invalid argument listing invalid fields
+type ValidationError struct {
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+func (e *ValidationError) Error() string {
+	fields := []string{}
+	for _, f := range e.Fields {
+		fields = append(fields, f.Field+": "+f.Message)
+	}
+	if len(fields) == 0 {
+		return e.Message
+	}
+	return e.Message + ": " + strings.Join(fields, ", ")
+}
+
+// Field returns validation error of a single field, e.g. to be returned
+// by Validate of input
+func Field(field, message string) *ValidationError {
+	return &ValidationError{
+		Message: "invalid input",
+		Fields:  []FieldError{{Field: field, Message: message}},
+	}
+}
+
+// Invalid converts error of validation into ValidationError, wrapped
+// ValidationError is returned as it is
+func Invalid(err error) *ValidationError {
+	var v *ValidationError
+	if errors.As(err, &v) {
+		return v
+	}
+	return &ValidationError{Message: err.Error()}
+}